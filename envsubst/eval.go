@@ -23,12 +23,12 @@ package envsubst
 import "os"
 
 // Eval replaces ${var} in the string based on the mapping function.
-func Eval(s string, mapping func(string) (string, bool)) (string, error) {
+func Eval(s string, mapping func(string) (string, bool), opts ...Option) (string, error) {
 	t, err := Parse(s)
 	if err != nil {
 		return s, err
 	}
-	return t.Execute(mapping)
+	return t.Execute(mapping, opts...)
 }
 
 // EvalEnv replaces ${var} in the string according to the values of the
@@ -42,6 +42,17 @@ func EvalEnv(s string, strict bool) (string, error) {
 	return Eval(s, mapping)
 }
 
+// EvalMap replaces ${var} in the string according to the values of the
+// given variables. References to undefined variables are replaced by the
+// empty string, unless strict is set, in which case an error is returned.
+func EvalMap(s string, vars map[string]string, strict bool) (string, error) {
+	mapping := func(name string) (string, bool) {
+		v, exists := vars[name]
+		return v, exists || !strict
+	}
+	return Eval(s, mapping)
+}
+
 func Getenv(s string) (string, bool) {
 	return os.Getenv(s), true
 }
@@ -70,6 +70,17 @@ func TestExpand(t *testing.T) {
 			input:  "${var01,,}",
 			output: "abcdefgh28ij",
 		},
+		// multibyte casing
+		{
+			params: map[string]string{"var01": "élan"},
+			input:  "${var01^} ${var01^^}",
+			output: "Élan ÉLAN",
+		},
+		{
+			params: map[string]string{"var01": "ÉLAN"},
+			input:  "${var01,} ${var01,,}",
+			output: "éLAN élan",
+		},
 		// substring with position
 		{
 			params: map[string]string{"path_name": "/home/bozo/ideas/thoughts.for.today"},
@@ -82,6 +93,17 @@ func TestExpand(t *testing.T) {
 			input:  "${path_name:11:5}",
 			output: "ideas",
 		},
+		// multibyte substring
+		{
+			params: map[string]string{"var01": "Halló heimur"},
+			input:  "${var01:3:2} ${var01:6}",
+			output: "ló heimur",
+		},
+		{
+			params: map[string]string{"var01": "日本語"},
+			input:  "${#var01} ${var01:0:${#var01}} ${var01:1:1}",
+			output: "3 日本語 本",
+		},
 		// default not used
 		{
 			params: map[string]string{"var": "abc"},
@@ -316,3 +338,132 @@ func TestExpandStrict(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalMap(t *testing.T) {
+	vars := map[string]string{"foo": "bar", "empty": ""}
+	var expressions = []struct {
+		input   string
+		strict  bool
+		output  string
+		wantErr error
+	}{
+		{input: "${foo}", output: "bar"},
+		{input: "${empty}", output: ""},
+		{input: "${missing}", output: ""},
+		{input: "${missing:=default}", output: "default"},
+		{input: "${foo}", strict: true, output: "bar"},
+		{input: "${empty}", strict: true, output: ""},
+		{input: "${missing}", strict: true, wantErr: errVarNotSet},
+		{input: "${missing:=default}", strict: true, output: "default"},
+	}
+
+	for _, expr := range expressions {
+		t.Run(expr.input, func(t *testing.T) {
+			output, err := EvalMap(expr.input, vars, expr.strict)
+			if expr.wantErr == nil && err != nil {
+				t.Errorf("Want %q expanded but got error %q", expr.input, err)
+			}
+			if expr.wantErr != nil && !errors.Is(err, expr.wantErr) {
+				t.Errorf("Want error %q but got error %q", expr.wantErr, err)
+			}
+			if expr.wantErr == nil && output != expr.output {
+				t.Errorf("Want %q expanded to %q, got %q",
+					expr.input,
+					expr.output,
+					output)
+			}
+		})
+	}
+}
+
+func TestEvalEnv(t *testing.T) {
+	t.Setenv("ENVSUBST_TEST_FOO", "bar")
+
+	var expressions = []struct {
+		input   string
+		strict  bool
+		output  string
+		wantErr error
+	}{
+		{input: "${ENVSUBST_TEST_FOO}", output: "bar"},
+		{input: "${ENVSUBST_TEST_MISSING}", output: ""},
+		{input: "${ENVSUBST_TEST_FOO}", strict: true, output: "bar"},
+		{input: "${ENVSUBST_TEST_MISSING}", strict: true, wantErr: errVarNotSet},
+		{input: "${ENVSUBST_TEST_MISSING:=default}", strict: true, output: "default"},
+	}
+
+	for _, expr := range expressions {
+		t.Run(expr.input, func(t *testing.T) {
+			output, err := EvalEnv(expr.input, expr.strict)
+			if expr.wantErr == nil && err != nil {
+				t.Errorf("Want %q expanded but got error %q", expr.input, err)
+			}
+			if expr.wantErr != nil && !errors.Is(err, expr.wantErr) {
+				t.Errorf("Want error %q but got error %q", expr.wantErr, err)
+			}
+			if expr.wantErr == nil && output != expr.output {
+				t.Errorf("Want %q expanded to %q, got %q",
+					expr.input,
+					expr.output,
+					output)
+			}
+		})
+	}
+}
+
+func TestExpandPreserveUnset(t *testing.T) {
+	var expressions = []struct {
+		params map[string]string
+		input  string
+		output string
+	}{
+		{
+			params: map[string]string{"foo": "bar"},
+			input:  "${foo} ${missing}",
+			output: "bar ${missing}",
+		},
+		{
+			params: map[string]string{"foo": ""},
+			input:  "${foo:=default}",
+			output: "default",
+		},
+		{
+			params: map[string]string{},
+			input:  "${missing:=default}",
+			output: "${missing:=default}",
+		},
+		{
+			params: map[string]string{"foo": "bar"},
+			input:  "${missing=${foo}} ${#missing} ${missing^^}",
+			output: "${missing=${foo}} ${#missing} ${missing^^}",
+		},
+		{
+			params: map[string]string{"foo": "bar"},
+			input:  "${foo=${missing}}",
+			output: "bar",
+		},
+		{
+			params: map[string]string{},
+			input:  `${missing/\//-} $${escaped}`,
+			output: `${missing/\//-} ${escaped}`,
+		},
+	}
+
+	for _, expr := range expressions {
+		t.Run(expr.input, func(t *testing.T) {
+			output, err := Eval(expr.input, func(s string) (string, bool) {
+				v, exists := expr.params[s]
+				return v, exists
+			}, WithPreserveUnset())
+			if err != nil {
+				t.Errorf("Want %q expanded but got error %q", expr.input, err)
+			}
+			if output != expr.output {
+				t.Errorf("Want %q expanded to %q, got %q",
+					expr.input,
+					expr.output,
+					output)
+			}
+		})
+	}
+}
@@ -32,9 +32,10 @@ import (
 // defines a parameter substitution function.
 type substituteFunc func(string, ...string) string
 
-// toLen returns the length of string s.
+// toLen returns the length of string s in characters, like bash does,
+// rather than in bytes.
 func toLen(s string, args ...string) string {
-	return strconv.Itoa(len(s))
+	return strconv.Itoa(utf8.RuneCountInString(s))
 }
 
 // toLower returns a copy of the string s with all characters
@@ -80,12 +81,14 @@ func toDefault(s string, args ...string) string {
 }
 
 // toSubstr returns a slice of the string s at the specified
-// length and position.
+// length and position, which are in characters like bash does,
+// rather than in bytes.
 func toSubstr(s string, args ...string) string {
 	if len(args) == 0 {
 		return s // should never happen
 	}
 
+	r := []rune(s)
 	pos, err := strconv.Atoi(args[0])
 	if err != nil {
 		// bash returns the string if the position
@@ -96,7 +99,7 @@ func toSubstr(s string, args ...string) string {
 	if pos < 0 {
 		// if pos is negative (counts from the end) add it
 		// to length to get first character offset
-		pos = len(s) + pos
+		pos = len(r) + pos
 
 		// if negative offset exceeds the length of the string
 		// start from 0
@@ -106,8 +109,8 @@ func toSubstr(s string, args ...string) string {
 	}
 
 	if len(args) == 1 {
-		if pos < len(s) {
-			return s[pos:]
+		if pos < len(r) {
+			return string(r[pos:])
 		}
 		// if the position exceeds the length of the
 		// string an empty string is returned
@@ -121,18 +124,18 @@ func toSubstr(s string, args ...string) string {
 		return s
 	}
 
-	if pos+length >= len(s) {
-		if pos < len(s) {
+	if pos+length >= len(r) {
+		if pos < len(r) {
 			// if the position exceeds the length of the
 			// string just return the rest of it like bash
-			return s[pos:]
+			return string(r[pos:])
 		}
 		// if the position exceeds the length of the
 		// string an empty string is returned
 		return ""
 	}
 
-	return s[pos : pos+length]
+	return string(r[pos : pos+length])
 }
 
 // replaceAll returns a copy of the string s with all instances
@@ -27,6 +27,16 @@ func Test_len(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect len function to return %s, got %s", want, got)
 	}
+
+	got, want = toLen("Halló heimur"), "12"
+	if got != want {
+		t.Errorf("Expect len function to return %s, got %s", want, got)
+	}
+
+	got, want = toLen("日本語"), "3"
+	if got != want {
+		t.Errorf("Expect len function to return %s, got %s", want, got)
+	}
 }
 
 func Test_lower(t *testing.T) {
@@ -34,6 +44,11 @@ func Test_lower(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect lower function to return %s, got %s", want, got)
 	}
+
+	got, want = toLower("ÉLAN ÜBER"), "élan über"
+	if got != want {
+		t.Errorf("Expect lower function to return %s, got %s", want, got)
+	}
 }
 
 func Test_lowerFirst(t *testing.T) {
@@ -41,6 +56,11 @@ func Test_lowerFirst(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect lowerFirst function to return %s, got %s", want, got)
 	}
+
+	got, want = toLowerFirst("ÉLAN"), "éLAN"
+	if got != want {
+		t.Errorf("Expect lowerFirst function to return %s, got %s", want, got)
+	}
 	defer func() {
 		if recover() != nil {
 			t.Errorf("Expect empty string does not panic lowerFirst")
@@ -54,6 +74,11 @@ func Test_upper(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect upper function to return %s, got %s", want, got)
 	}
+
+	got, want = toUpper("élan über"), "ÉLAN ÜBER"
+	if got != want {
+		t.Errorf("Expect upper function to return %s, got %s", want, got)
+	}
 }
 
 func Test_upperFirst(t *testing.T) {
@@ -61,6 +86,11 @@ func Test_upperFirst(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect upperFirst function to return %s, got %s", want, got)
 	}
+
+	got, want = toUpperFirst("élan"), "Élan"
+	if got != want {
+		t.Errorf("Expect upperFirst function to return %s, got %s", want, got)
+	}
 	defer func() {
 		if recover() != nil {
 			t.Errorf("Expect empty string does not panic upperFirst")
@@ -121,4 +151,19 @@ func Test_substr(t *testing.T) {
 	if got != want {
 		t.Errorf("Expect substr function to cut entire string if pos is itself out of bound")
 	}
+
+	got, want = toSubstr("Halló heimur", "3", "3"), "ló "
+	if got != want {
+		t.Errorf("Expect substr function to count offset and length in characters. Got %s, Want %s", got, want)
+	}
+
+	got, want = toSubstr("日本語", "-2"), "本語"
+	if got != want {
+		t.Errorf("Expect substr function to count negative offsets in characters. Got %s, Want %s", got, want)
+	}
+
+	got, want = toSubstr("日本語", "0", toLen("日本語")), "日本語"
+	if got != want {
+		t.Errorf("Expect substr function to return the entire string for its length. Got %s, Want %s", got, want)
+	}
 }
@@ -41,6 +41,9 @@ type (
 		Param string
 		Name  string
 		Args  []Node
+		// Text is the source text of the function, including the
+		// enclosing "${" and "}".
+		Text string
 	}
 
 	// ListNode represents a list of nodes.
@@ -106,7 +106,21 @@ func (t *Tree) parseAny() (Node, error) {
 	return nil, ErrBadSubstitution
 }
 
+// parseFunc parses a function, and records its source text. The opening
+// bracket must be the most recently scanned token.
 func (t *Tree) parseFunc() (Node, error) {
+	start := t.scanner.offset(t.scanner.start)
+	node, err := t.parseFuncNode()
+	if err != nil {
+		return nil, err
+	}
+	if fn, ok := node.(*FuncNode); ok {
+		fn.Text = t.scanner.src[start:t.scanner.offset(t.scanner.pos)]
+	}
+	return node, nil
+}
+
+func (t *Tree) parseFuncNode() (Node, error) {
 	// Turn on all escape characters
 	t.scanner.escapeChars = escapeAll
 	switch t.scanner.peek() {
@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 var tests = []struct {
@@ -543,7 +544,65 @@ func TestParse(t *testing.T) {
 				t.Error(err)
 			}
 
-			if diff := cmp.Diff(test.Node, got.Root); diff != "" {
+			if diff := cmp.Diff(test.Node, got.Root, cmpopts.IgnoreFields(FuncNode{}, "Text")); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestParse_funcText(t *testing.T) {
+	var tests = []struct {
+		input string
+		texts []string
+	}{
+		{
+			input: "${string}",
+			texts: []string{"${string}"},
+		},
+		{
+			input: "text ${#string} text",
+			texts: []string{"${#string}"},
+		},
+		{
+			input: "$${escaped} ${string:-default}",
+			texts: []string{"${string:-default}"},
+		},
+		{
+			input: `${string/\//-}`,
+			texts: []string{`${string/\//-}`},
+		},
+		{
+			input: "${string=prefix-${var}-suffix} ${other^^}",
+			texts: []string{"${string=prefix-${var}-suffix}", "${var}", "${other^^}"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var texts []string
+			var walk func(Node)
+			walk = func(n Node) {
+				switch n := n.(type) {
+				case *ListNode:
+					for _, c := range n.Nodes {
+						walk(c)
+					}
+				case *FuncNode:
+					texts = append(texts, n.Text)
+					for _, c := range n.Args {
+						walk(c)
+					}
+				}
+			}
+			walk(got.Root)
+
+			if diff := cmp.Diff(test.texts, texts); diff != "" {
 				t.Errorf(diff)
 			}
 		})
@@ -67,7 +67,9 @@ type acceptFunc func(r rune, i int) bool
 // scanner implements a lexical scanner that reads unicode
 // characters and tokens from a string buffer.
 type scanner struct {
+	src         string
 	buf         string
+	skipped     int
 	pos         int
 	start       int
 	width       int
@@ -79,7 +81,9 @@ type scanner struct {
 
 // init initializes a scanner with a new buffer.
 func (s *scanner) init(buf string) {
+	s.src = buf
 	s.buf = buf
+	s.skipped = 0
 	s.pos = 0
 	s.start = 0
 	s.width = 0
@@ -109,6 +113,13 @@ func (s *scanner) skip() {
 	l := s.buf[:s.pos-1]
 	r := s.buf[s.pos:]
 	s.buf = l + r
+	s.skipped++
+}
+
+// offset returns the position in the source corresponding to the given
+// position in the buffer, accounting for the skipped characters.
+func (s *scanner) offset(pos int) int {
+	return pos + s.skipped
 }
 
 // peek returns the next unicode character in the buffer without
@@ -38,6 +38,26 @@ type state struct {
 
 	// maps variable names to values
 	mapper func(string) (value string, exists bool)
+
+	options options
+}
+
+// Option configures the execution of a template.
+type Option func(*options)
+
+type options struct {
+	preserveUnset bool
+	maxOutputSize int
+}
+
+// WithPreserveUnset instructs the template execution to write the source
+// text of any function referencing a variable which does not exist, for
+// example "${var}" or "${var:=default}", instead of evaluating it. This
+// allows the output to be substituted again in a later pass.
+func WithPreserveUnset() Option {
+	return func(o *options) {
+		o.preserveUnset = true
+	}
 }
 
 // Template is the representation of a parsed shell format string.
@@ -66,13 +86,37 @@ func ParseFile(path string) (*Template, error) {
 	return Parse(string(b))
 }
 
+// WithMaxOutputSize limits the size of the output of the template
+// execution to n bytes. The execution is aborted with an error as soon as
+// the limit is exceeded, which bounds the memory used to execute untrusted
+// templates. A limit of zero or less means no limit.
+func WithMaxOutputSize(n int) Option {
+	return func(o *options) {
+		o.maxOutputSize = n
+	}
+}
+
+// ParseReader creates a new shell format template and parses the template
+// definition read from r. An error reading from r is returned wrapped, and
+// is distinguishable from a parse error using errors.Is.
+func ParseReader(r io.Reader) (*Template, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template: %w", err)
+	}
+	return Parse(string(b))
+}
+
 // Execute applies a parsed template to the specified data mapping.
-func (t *Template) Execute(mapping func(string) (string, bool)) (str string, err error) {
+func (t *Template) Execute(mapping func(string) (string, bool), opts ...Option) (str string, err error) {
 	b := new(bytes.Buffer)
 	s := new(state)
 	s.node = t.tree.Root
 	s.mapper = mapping
-	s.writer = b
+	for _, o := range opts {
+		o(&s.options)
+	}
+	s.writer = s.limit(b)
 	err = t.eval(s)
 	if err != nil {
 		return
@@ -110,13 +154,46 @@ func (t *Template) evalList(s *state, node *parse.ListNode) (err error) {
 
 var errVarNotSet = fmt.Errorf("variable not set (strict mode)")
 
+var errMaxOutputSize = fmt.Errorf("maximum output size exceeded")
+
+// limit returns w limited to the maximum output size, if configured.
+func (s *state) limit(w io.Writer) io.Writer {
+	if s.options.maxOutputSize <= 0 {
+		return w
+	}
+	return &limitedWriter{w: w, limit: s.options.maxOutputSize}
+}
+
+// limitedWriter writes to w until the limit of bytes has been written,
+// after which it returns an error.
+type limitedWriter struct {
+	w       io.Writer
+	limit   int
+	written int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.written+len(p) > l.limit {
+		return 0, fmt.Errorf("%w: limit is %d bytes", errMaxOutputSize, l.limit)
+	}
+	n, err := l.w.Write(p)
+	l.written += n
+	return n, err
+}
+
 func (t *Template) evalFunc(s *state, node *parse.FuncNode) error {
+	v, exists := s.mapper(node.Param)
+	if !exists && s.options.preserveUnset {
+		_, err := io.WriteString(s.writer, node.Text)
+		return err
+	}
+
 	var w = s.writer
 	var buf bytes.Buffer
 	var args []string
 	for _, n := range node.Args {
 		buf.Reset()
-		s.writer = &buf
+		s.writer = s.limit(&buf)
 		s.node = n
 		err := t.eval(s)
 		if err != nil {
@@ -129,8 +206,6 @@ func (t *Template) evalFunc(s *state, node *parse.FuncNode) error {
 	s.writer = w
 	s.node = node
 
-	v, exists := s.mapper(node.Param)
-
 	if node.Name == "" && !exists {
 		return fmt.Errorf("%w: %q", errVarNotSet, node.Param)
 	}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envsubst
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/fluxcd/pkg/envsubst/parse"
+)
+
+func TestParseReader(t *testing.T) {
+	errRead := errors.New("read error")
+
+	var tests = []struct {
+		name    string
+		reader  io.Reader
+		output  string
+		wantErr error
+	}{
+		{
+			name:   "template",
+			reader: strings.NewReader("foo: ${foo}"),
+			output: "foo: bar",
+		},
+		{
+			name:   "one byte at a time",
+			reader: iotest.OneByteReader(strings.NewReader("foo: ${foo^^}")),
+			output: "foo: BAR",
+		},
+		{
+			name:    "read error mid-stream",
+			reader:  io.MultiReader(strings.NewReader("foo: ${foo"), iotest.ErrReader(errRead)),
+			wantErr: errRead,
+		},
+		{
+			name:    "parse error",
+			reader:  strings.NewReader("foo: ${foo"),
+			wantErr: parse.ErrMissingClosingBrace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseReader(tt.reader)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Want error %q but got error %q", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Want template parsed but got error %q", err)
+			}
+
+			output, err := tmpl.Execute(func(s string) (string, bool) {
+				return map[string]string{"foo": "bar"}[s], true
+			})
+			if err != nil {
+				t.Errorf("Want template executed but got error %q", err)
+			}
+			if output != tt.output {
+				t.Errorf("Want template executed to %q, got %q", tt.output, output)
+			}
+		})
+	}
+}
+
+func TestExecute_maxOutputSize(t *testing.T) {
+	params := map[string]string{"foo": "bar", "big": strings.Repeat("x", 64)}
+
+	var tests = []struct {
+		name    string
+		input   string
+		max     int
+		output  string
+		wantErr error
+	}{
+		{
+			name:   "no limit",
+			input:  "${big}${big}",
+			output: strings.Repeat("x", 128),
+		},
+		{
+			name:   "under the limit",
+			input:  "foo: ${foo}",
+			max:    16,
+			output: "foo: bar",
+		},
+		{
+			name:   "at the limit",
+			input:  "${big}",
+			max:    64,
+			output: strings.Repeat("x", 64),
+		},
+		{
+			name:    "over the limit",
+			input:   "${big}${big}",
+			max:     100,
+			wantErr: errMaxOutputSize,
+		},
+		{
+			name:    "over the limit in function arguments",
+			input:   "${missing=${big}${big}}",
+			max:     100,
+			wantErr: errMaxOutputSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Want template parsed but got error %q", err)
+			}
+
+			output, err := tmpl.Execute(func(s string) (string, bool) {
+				v, exists := params[s]
+				return v, exists
+			}, WithMaxOutputSize(tt.max))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Want error %q but got error %q", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Want template executed but got error %q", err)
+			}
+			if output != tt.output {
+				t.Errorf("Want template executed to %q, got %q", tt.output, output)
+			}
+		})
+	}
+}
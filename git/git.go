@@ -188,6 +188,17 @@ func (e ErrRepositoryNotFound) Error() string {
 var (
 	ErrNoGitRepository = errors.New("no git repository")
 	ErrNoStagedFiles   = errors.New("no staged files")
+	ErrMergeConflict   = errors.New("merge conflict")
+	ErrBranchNotFound  = errors.New("branch not found")
+
+	// ErrNoChanges is returned by a commit which would not change anything
+	// compared to HEAD, and is therefore not created. It wraps
+	// ErrNoStagedFiles for compatibility.
+	ErrNoChanges = fmt.Errorf("%w: no changes to commit", ErrNoStagedFiles)
+
+	// ErrAlreadyMerged is returned by a merge of a reference which is
+	// already reachable from HEAD, and therefore does not create a commit.
+	ErrAlreadyMerged = errors.New("already merged")
 )
 
 // IsConcreteCommit returns if a given commit is a concrete commit. Concrete
@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/go-git/go-billy/v5"
@@ -78,6 +79,7 @@ type Client struct {
 	useDefaultKnownHosts bool
 	singleBranch         bool
 	proxy                transport.ProxyOptions
+	insecureSkipTLS      bool
 }
 
 var _ repository.Client = &Client{}
@@ -114,6 +116,9 @@ func NewClient(path string, authOpts *git.AuthOptions, clientOpts ...ClientOptio
 	if g.worktreeFS == nil {
 		return nil, errors.New("unable to create client with a nil worktree filesystem")
 	}
+	if g.insecureSkipTLS && caBundle(g.authOpts) != nil {
+		return nil, errors.New("unable to create client with a CA bundle while skipping TLS verification")
+	}
 
 	return g, nil
 }
@@ -205,6 +210,17 @@ func WithProxy(opts transport.ProxyOptions) ClientOption {
 	}
 }
 
+// WithInsecureSkipTLSVerify disables the verification of the TLS
+// certificate of the Git server for all HTTPS remote operations. This is
+// unsafe, and is only intended for test and development environments with
+// self-signed certificates. It can not be combined with a CA bundle.
+func WithInsecureSkipTLSVerify() ClientOption {
+	return func(c *Client) error {
+		c.insecureSkipTLS = true
+		return nil
+	}
+}
+
 func (g *Client) Init(ctx context.Context, url, branch string) error {
 	if err := g.validateUrl(url); err != nil {
 		return err
@@ -251,35 +267,57 @@ func (g *Client) Clone(ctx context.Context, url string, cfg repository.CloneConf
 		return nil, err
 	}
 
+	var (
+		commit *git.Commit
+		err    error
+	)
 	checkoutStrat := cfg.CheckoutStrategy
 	switch {
 	case checkoutStrat.Commit != "":
-		return g.cloneCommit(ctx, url, checkoutStrat.Commit, cfg)
+		commit, err = g.cloneCommit(ctx, url, checkoutStrat.Commit, cfg)
 	case checkoutStrat.RefName != "":
-		return g.cloneRefName(ctx, url, checkoutStrat.RefName, cfg)
+		commit, err = g.cloneRefName(ctx, url, checkoutStrat.RefName, cfg)
 	case checkoutStrat.Tag != "":
-		return g.cloneTag(ctx, url, checkoutStrat.Tag, cfg)
+		commit, err = g.cloneTag(ctx, url, checkoutStrat.Tag, cfg)
 	case checkoutStrat.SemVer != "":
-		return g.cloneSemVer(ctx, url, checkoutStrat.SemVer, cfg)
+		commit, err = g.cloneSemVer(ctx, url, checkoutStrat.SemVer, cfg)
 	default:
 		branch := checkoutStrat.Branch
 		if branch == "" {
 			branch = git.DefaultBranch
 		}
-		return g.cloneBranch(ctx, url, branch, cfg)
+		commit, err = g.cloneBranch(ctx, url, branch, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only verify the content if a worktree was checked out, i.e. the
+	// clone was not skipped nor of an empty repository.
+	if cfg.ExpectedContentDigest != "" && commit != nil && git.IsConcreteCommit(*commit) {
+		if err := g.verifyContentDigest(cfg.ExpectedContentDigest); err != nil {
+			return nil, err
+		}
 	}
+	return commit, nil
 }
 
 func (g *Client) validateUrl(u string) error {
+	return g.validateUrlWithAuth(u, g.authOpts)
+}
+
+// validateUrlWithAuth validates the URL against the given authentication
+// options.
+func (g *Client) validateUrlWithAuth(u string, authOpts *git.AuthOptions) error {
 	ru, err := url.Parse(u)
 	if err != nil {
 		return fmt.Errorf("cannot parse url: %w", err)
 	}
 
-	if g.authOpts != nil {
-		httpOrHttps := g.authOpts.Transport == git.HTTP || g.authOpts.Transport == git.HTTPS
-		hasUsernameOrPassword := g.authOpts.Username != "" || g.authOpts.Password != ""
-		hasBearerToken := g.authOpts.BearerToken != ""
+	if authOpts != nil {
+		httpOrHttps := authOpts.Transport == git.HTTP || authOpts.Transport == git.HTTPS
+		hasUsernameOrPassword := authOpts.Username != "" || authOpts.Password != ""
+		hasBearerToken := authOpts.BearerToken != ""
 
 		if httpOrHttps && hasBearerToken && hasUsernameOrPassword {
 			return errors.New("basic auth and bearer token cannot be set at the same time")
@@ -295,10 +333,10 @@ func (g *Client) validateUrl(u string) error {
 		return errors.New("URL cannot contain credentials when using HTTP")
 	}
 
-	if httpOrEmpty && g.authOpts != nil {
-		if g.authOpts.Username != "" || g.authOpts.Password != "" {
+	if httpOrEmpty && authOpts != nil {
+		if authOpts.Username != "" || authOpts.Password != "" {
 			return errors.New("basic auth cannot be sent over HTTP")
-		} else if g.authOpts.BearerToken != "" {
+		} else if authOpts.BearerToken != "" {
 			return errors.New("bearer token cannot be sent over HTTP")
 		}
 	}
@@ -306,6 +344,33 @@ func (g *Client) validateUrl(u string) error {
 	return nil
 }
 
+// validateRenames returns an error if the result of the given renames
+// depends on the order they are applied in, which is the case if a file is
+// renamed to the old path of another rename, or if several files are
+// renamed to the same path.
+func validateRenames(renames map[string]string) error {
+	oldPaths := make([]string, 0, len(renames))
+	cleaned := map[string]struct{}{}
+	for oldPath := range renames {
+		oldPaths = append(oldPaths, oldPath)
+		cleaned[filepath.Clean(oldPath)] = struct{}{}
+	}
+	sort.Strings(oldPaths)
+
+	newPaths := map[string]string{}
+	for _, oldPath := range oldPaths {
+		newPath := filepath.Clean(renames[oldPath])
+		if _, ok := cleaned[newPath]; ok {
+			return fmt.Errorf("unable to rename '%s' to '%s': '%s' is renamed as well", oldPath, renames[oldPath], newPath)
+		}
+		if other, ok := newPaths[newPath]; ok {
+			return fmt.Errorf("unable to rename '%s' to '%s': '%s' is renamed to it as well", oldPath, renames[oldPath], other)
+		}
+		newPaths[newPath] = oldPath
+	}
+	return nil
+}
+
 func (g *Client) writeFile(path string, reader io.Reader) error {
 	if g.repository == nil {
 		return git.ErrNoGitRepository
@@ -321,6 +386,11 @@ func (g *Client) writeFile(path string, reader io.Reader) error {
 	return err
 }
 
+// Commit writes the files provided with the commit options to the
+// worktree, stages all the changes in the worktree and commits them.
+// If the staged changes do not differ from HEAD, no commit is created and
+// the hash of HEAD is returned together with git.ErrNoChanges. To check for
+// changes without committing, use ChangedFiles or IsClean.
 func (g *Client) Commit(info git.Commit, commitOpts ...repository.CommitOption) (string, error) {
 	if g.repository == nil {
 		return "", git.ErrNoGitRepository
@@ -331,12 +401,27 @@ func (g *Client) Commit(info git.Commit, commitOpts ...repository.CommitOption)
 		o(options)
 	}
 
+	if err := validateRenames(options.RenamedFiles); err != nil {
+		return "", err
+	}
+	for oldPath, newPath := range options.RenamedFiles {
+		if err := g.worktreeFS.Rename(oldPath, newPath); err != nil {
+			return "", fmt.Errorf("unable to rename '%s' to '%s': %w", oldPath, newPath, err)
+		}
+	}
+
 	for path, content := range options.Files {
 		if err := g.writeFile(path, content); err != nil {
 			return "", err
 		}
 	}
 
+	for _, path := range options.DeletedFiles {
+		if err := g.worktreeFS.Remove(path); err != nil {
+			return "", fmt.Errorf("unable to delete '%s': %w", path, err)
+		}
+	}
+
 	wt, err := g.repository.Worktree()
 	if err != nil {
 		return "", err
@@ -358,15 +443,13 @@ func (g *Client) Commit(info git.Commit, commitOpts ...repository.CommitOption)
 		if err != nil {
 			return "", err
 		}
-		return head.Hash().String(), git.ErrNoStagedFiles
+		return head.Hash().String(), git.ErrNoChanges
 	}
 
+	author, committer := commitSignatures(info, time.Now())
 	opts := &extgogit.CommitOptions{
-		Author: &object.Signature{
-			Name:  info.Author.Name,
-			Email: info.Author.Email,
-			When:  time.Now(),
-		},
+		Author:    &author,
+		Committer: &committer,
 	}
 
 	if options.Signer != nil {
@@ -380,12 +463,95 @@ func (g *Client) Commit(info git.Commit, commitOpts ...repository.CommitOption)
 	return commit.String(), nil
 }
 
+// VerifyCommit verifies the OpenPGP signature of the commit the given
+// revision resolves to, using the given armored key rings. It returns the
+// key ID of the key the commit was signed with.
+func (g *Client) VerifyCommit(rev string, keyRings ...string) (string, error) {
+	if g.repository == nil {
+		return "", git.ErrNoGitRepository
+	}
+
+	hash, err := g.repository.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve '%s': %w", rev, err)
+	}
+	c, err := g.repository.CommitObject(*hash)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve commit object for '%s': %w", rev, err)
+	}
+	cc, err := buildCommitWithRef(c, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return cc.Verify(keyRings...)
+}
+
+// VerifyTag verifies the OpenPGP signature of the annotated tag with the
+// given name, using the given armored key rings. It returns the key ID of
+// the key the tag was signed with.
+func (g *Client) VerifyTag(name string, keyRings ...string) (string, error) {
+	if g.repository == nil {
+		return "", git.ErrNoGitRepository
+	}
+
+	ref, err := g.repository.Tag(name)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve tag '%s': %w", name, err)
+	}
+	t, err := g.repository.TagObject(ref.Hash())
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve tag object for '%s': %w", name, err)
+	}
+	tt, err := buildTag(t, ref.Name())
+	if err != nil {
+		return "", err
+	}
+	return tt.Verify(keyRings...)
+}
+
+// commitSignatures returns the author and committer signatures for a new
+// commit with the given information. The committer defaults to the author
+// if info.Committer is not set.
+func commitSignatures(info git.Commit, when time.Time) (author, committer object.Signature) {
+	author = object.Signature{
+		Name:  info.Author.Name,
+		Email: info.Author.Email,
+		When:  when,
+	}
+	committer = author
+	if info.Committer.Name != "" || info.Committer.Email != "" {
+		committer = object.Signature{
+			Name:  info.Committer.Name,
+			Email: info.Committer.Email,
+			When:  when,
+		}
+	}
+	return author, committer
+}
+
 func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 	if g.repository == nil {
 		return git.ErrNoGitRepository
 	}
 
-	authMethod, err := transportAuth(g.authOpts, g.useDefaultKnownHosts)
+	if cfg.Mirror && cfg.RemoteURL == "" {
+		return errors.New("unable to mirror push without a remote URL")
+	}
+
+	authOpts := g.authOpts
+	if cfg.RemoteURL != "" {
+		if cfg.ForceWithLease && !cfg.Mirror {
+			return errors.New("unable to force push with lease to a remote URL")
+		}
+		if cfg.AuthOptions != nil {
+			authOpts = cfg.AuthOptions
+		}
+		if err := g.validateUrlWithAuth(cfg.RemoteURL, authOpts); err != nil {
+			return err
+		}
+	}
+
+	authMethod, err := transportAuth(authOpts, g.useDefaultKnownHosts)
 	if err != nil {
 		return fmt.Errorf("failed to construct auth method with options: %w", err)
 	}
@@ -395,6 +561,65 @@ func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 		refspecs = append(refspecs, config.RefSpec(ref))
 	}
 
+	var remote *extgogit.Remote
+	if cfg.RemoteURL != "" {
+		// Use an anonymous remote, to not update the remote-tracking
+		// references of origin with the references pushed to the URL.
+		remote = extgogit.NewRemote(g.repository.Storer, &config.RemoteConfig{
+			Name: "anonymous",
+			URLs: []string{cfg.RemoteURL},
+		})
+	} else {
+		remote, err = g.repository.Remote(extgogit.DefaultRemoteName)
+		if err != nil {
+			return err
+		}
+	}
+
+	force := cfg.Force
+	var forceWithLease *extgogit.ForceWithLease
+	if cfg.ForceWithLease {
+		// An empty lease protects all the pushed references, by expecting
+		// them to match the remote-tracking references of origin.
+		forceWithLease = &extgogit.ForceWithLease{}
+		force = false
+	}
+	if cfg.Mirror {
+		// Mirror all the local branches and tags, the remote-tracking
+		// references of the repository are left out.
+		refspecs = []config.RefSpec{
+			"+refs/heads/*:refs/heads/*",
+			"+refs/tags/*:refs/tags/*",
+		}
+		force = true
+		forceWithLease = nil
+
+		// go-git does not match forced refspecs correctly when pruning,
+		// the references to prune are therefore deleted explicitly.
+		remoteRefs, err := remote.ListContext(ctx, &extgogit.ListOptions{
+			Auth:            authMethod,
+			CABundle:        caBundle(authOpts),
+			InsecureSkipTLS: g.insecureSkipTLS,
+			ProxyOptions:    g.proxy,
+		})
+		if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return fmt.Errorf("unable to list references of remote: %w", err)
+		}
+		for _, ref := range remoteRefs {
+			if !ref.Name().IsBranch() && !ref.Name().IsTag() {
+				continue
+			}
+			_, err := g.repository.Reference(ref.Name(), false)
+			if errors.Is(err, plumbing.ErrReferenceNotFound) {
+				refspecs = append(refspecs, config.RefSpec(":"+ref.Name().String()))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// If no refspecs were provided, we need to push the current ref HEAD points to.
 	// The format of a refspec for a Git push is generally something like
 	// "refs/heads/branch:refs/heads/branch".
@@ -408,15 +633,17 @@ func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 		refspecs = append(refspecs, headRefspec)
 	}
 
-	err = g.repository.PushContext(ctx, &extgogit.PushOptions{
-		RefSpecs:     refspecs,
-		Force:        cfg.Force,
-		RemoteName:   extgogit.DefaultRemoteName,
-		Auth:         authMethod,
-		Progress:     nil,
-		CABundle:     caBundle(g.authOpts),
-		ProxyOptions: g.proxy,
-		Options:      cfg.Options,
+	err = remote.PushContext(ctx, &extgogit.PushOptions{
+		RefSpecs:        refspecs,
+		Force:           force,
+		ForceWithLease:  forceWithLease,
+		RemoteName:      remote.Config().Name,
+		Auth:            authMethod,
+		Progress:        nil,
+		CABundle:        caBundle(authOpts),
+		InsecureSkipTLS: g.insecureSkipTLS,
+		ProxyOptions:    g.proxy,
+		Options:         cfg.Options,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to push to remote: %w", err)
@@ -425,6 +652,57 @@ func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 	return nil
 }
 
+// Fetch fetches the references matching the configured refspecs from the
+// origin remote into the existing repository, updating the remote-tracking
+// references without requiring a new clone. The worktree is not updated.
+func (g *Client) Fetch(ctx context.Context, cfg repository.FetchConfig) error {
+	if g.repository == nil {
+		return git.ErrNoGitRepository
+	}
+
+	remote, err := g.repository.Remote(extgogit.DefaultRemoteName)
+	if err != nil {
+		return fmt.Errorf("failed to load remote '%s': %w", extgogit.DefaultRemoteName, err)
+	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		if err := g.validateUrl(urls[0]); err != nil {
+			return err
+		}
+	}
+
+	authMethod, err := transportAuth(g.authOpts, g.useDefaultKnownHosts)
+	if err != nil {
+		return fmt.Errorf("failed to construct auth method with options: %w", err)
+	}
+
+	var refspecs []config.RefSpec
+	for _, ref := range cfg.Refspecs {
+		refspecs = append(refspecs, config.RefSpec(ref))
+	}
+
+	tags := extgogit.NoTags
+	if cfg.Tags {
+		tags = extgogit.AllTags
+	}
+
+	err = g.repository.FetchContext(ctx, &extgogit.FetchOptions{
+		RemoteName:      extgogit.DefaultRemoteName,
+		RefSpecs:        refspecs,
+		Auth:            authMethod,
+		Progress:        nil,
+		Tags:            tags,
+		CABundle:        caBundle(g.authOpts),
+		InsecureSkipTLS: g.insecureSkipTLS,
+		ProxyOptions:    g.proxy,
+		Prune:           cfg.Prune,
+	})
+	if err != nil && !errors.Is(err, extgogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch from remote: %w", err)
+	}
+
+	return nil
+}
+
 // SwitchBranch switches the current branch to the given branch name.
 //
 // No new references are fetched from the remote during the process,
@@ -439,7 +717,8 @@ func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 //
 // The following cases are handled:
 // - Branch does not exist results in one being created using HEAD
-// of the worktree.
+// of the worktree. With WithSwitchBranchMode(SwitchBranchStrict),
+// git.ErrBranchNotFound is returned instead.
 // - Branch exists only remotely, results in a local branch being
 // created tracking the remote HEAD.
 // - Branch exists only locally, results in a checkout to the
@@ -451,11 +730,18 @@ func (g *Client) Push(ctx context.Context, cfg repository.PushConfig) error {
 // (i.e. image automation controller), use WithForcePush(true) in
 // combination with WithSingleBranch(true). This will ignore the
 // remote branch's existence.
-func (g *Client) SwitchBranch(ctx context.Context, branchName string) error {
+func (g *Client) SwitchBranch(ctx context.Context, branchName string, switchOpts ...repository.SwitchBranchOption) error {
 	if g.repository == nil {
 		return git.ErrNoGitRepository
 	}
 
+	options := &repository.SwitchBranchOptions{
+		Mode: repository.SwitchBranchCreateIfMissing,
+	}
+	for _, o := range switchOpts {
+		o(options)
+	}
+
 	wt, err := g.repository.Worktree()
 	if err != nil {
 		return fmt.Errorf("failed to load worktree: %w", err)
@@ -490,6 +776,9 @@ func (g *Client) SwitchBranch(ctx context.Context, branchName string) error {
 			return fmt.Errorf("could not create reference to remote HEAD '%s': %w", branchRef.Hash().String(), err)
 		}
 	} else if !remote && !local {
+		if options.Mode == repository.SwitchBranchStrict {
+			return fmt.Errorf("%w: '%s'", git.ErrBranchNotFound, branchName)
+		}
 		// If the target branch does not exist locally or remotely, create a new
 		// branch using the current worktree HEAD.
 		create = true
@@ -521,6 +810,31 @@ func (g *Client) IsClean() (bool, error) {
 	return status.IsClean(), nil
 }
 
+// ChangedFiles returns the sorted paths of the files in the worktree which
+// differ from HEAD, including untracked files. These are the changes a
+// Commit would include. It can be used to preview a commit after making
+// changes to the worktree, and is empty if a Commit would return
+// git.ErrNoChanges.
+func (g *Client) ChangedFiles() ([]string, error) {
+	if g.repository == nil {
+		return nil, git.ErrNoGitRepository
+	}
+	wt, err := g.repository.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(status))
+	for file := range status {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 func (g *Client) Head() (string, error) {
 	if g.repository == nil {
 		return "", git.ErrNoGitRepository
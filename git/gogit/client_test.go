@@ -17,16 +17,22 @@ limitations under the License.
 package gogit
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	extgogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	. "github.com/onsi/gomega"
 
 	"github.com/fluxcd/pkg/git"
@@ -46,6 +52,56 @@ func TestNewClient(t *testing.T) {
 	g.Expect(ggc.path).To(Equal(filepath.Join(wd, "outside")))
 }
 
+func TestNewClient_insecureSkipTLSVerify(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := NewClient(t.TempDir(), &git.AuthOptions{
+		Transport: git.HTTPS,
+		CAFile:    []byte("ca"),
+	}, WithDiskStorage(), WithInsecureSkipTLSVerify())
+	g.Expect(err).To(MatchError("unable to create client with a CA bundle while skipping TLS verification"))
+}
+
+func TestClone_insecureSkipTLSVerify(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := gittestserver.NewTempGitServer()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+
+	err = server.InitRepo("../testdata/git/repo", git.DefaultBranch, "test.git")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	cert, err := os.ReadFile("../testdata/certs/server.pem")
+	g.Expect(err).ToNot(HaveOccurred())
+	key, err := os.ReadFile("../testdata/certs/server-key.pem")
+	g.Expect(err).ToNot(HaveOccurred())
+	ca, err := os.ReadFile("../testdata/certs/ca.pem")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(server.StartHTTPS(cert, key, ca, "example.com")).To(Succeed())
+	defer server.StopHTTP()
+
+	repoURL := server.HTTPAddress() + "/test.git"
+	authOpts := &git.AuthOptions{Transport: git.HTTPS}
+
+	// The certificate of the server is not trusted.
+	ggc, err := NewClient(t.TempDir(), authOpts)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = ggc.Clone(context.TODO(), repoURL, repository.CloneConfig{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("certificate"))
+
+	ggc, err = NewClient(t.TempDir(), authOpts, WithDiskStorage(), WithInsecureSkipTLSVerify())
+	g.Expect(err).ToNot(HaveOccurred())
+	cc, err := ggc.Clone(context.TODO(), repoURL, repository.CloneConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cc).ToNot(BeNil())
+
+	head, err := ggc.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(head).To(Equal(cc.Hash.String()))
+}
+
 func TestInit(t *testing.T) {
 	g := NewWithT(t)
 
@@ -153,7 +209,8 @@ func TestCommit(t *testing.T) {
 			Email: "test@example.com",
 		},
 	})
-	g.Expect(err).To(Equal(git.ErrNoStagedFiles))
+	g.Expect(err).To(MatchError(git.ErrNoChanges))
+	g.Expect(err).To(MatchError(git.ErrNoStagedFiles))
 	g.Expect(hash).To(Equal(cc))
 
 	cc, err = ggc.Commit(
@@ -173,6 +230,332 @@ func TestCommit(t *testing.T) {
 	g.Expect(cc).ToNot(Equal(hash))
 }
 
+func TestCommit_changedFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantFiles []string
+	}{
+		{
+			name:      "changed",
+			files:     map[string]string{"test": "changed content", "new": "new content"},
+			wantFiles: []string{"new", "test"},
+		},
+		{
+			name:      "unchanged",
+			files:     map[string]string{"test": "content"},
+			wantFiles: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := t.TempDir()
+			repo, err := extgogit.PlainInit(path, false)
+			g.Expect(err).ToNot(HaveOccurred())
+			head, err := commitFile(repo, "test", "content", time.Now())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ggc, err := NewClient(path, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			ggc.repository = repo
+
+			// Preview the changes before committing them.
+			for name, content := range tt.files {
+				g.Expect(ggc.writeFile(name, strings.NewReader(content))).To(Succeed())
+			}
+			files, err := ggc.ChangedFiles()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(files).To(Equal(tt.wantFiles))
+
+			cc, err := ggc.Commit(git.Commit{
+				Author: git.Signature{
+					Name:  "Test User",
+					Email: "test@example.com",
+				},
+				Message: "testing",
+			})
+			if len(tt.wantFiles) == 0 {
+				g.Expect(err).To(MatchError(git.ErrNoChanges))
+				g.Expect(cc).To(Equal(head.String()))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cc).ToNot(Equal(head.String()))
+
+			files, err = ggc.ChangedFiles()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(files).To(BeEmpty())
+		})
+	}
+}
+
+func TestCommit_deletedAndRenamedFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	path := t.TempDir()
+	repo, err := extgogit.PlainInit(path, false)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(path, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	author := git.Signature{
+		Name:  "Test User",
+		Email: "test@example.com",
+	}
+	_, err = ggc.Commit(git.Commit{Author: author, Message: "add files"},
+		repository.WithFiles(map[string]io.Reader{
+			"delete":     strings.NewReader("to be deleted"),
+			"rename":     strings.NewReader("to be renamed"),
+			"dir/delete": strings.NewReader("to be deleted"),
+		}),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	cc, err := ggc.Commit(git.Commit{Author: author, Message: "delete and rename files"},
+		repository.WithDeletedFiles([]string{"delete", "dir/delete"}),
+		repository.WithRenamedFiles(map[string]string{"rename": "renamed/file"}),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	commit, err := repo.CommitObject(plumbing.NewHash(cc))
+	g.Expect(err).ToNot(HaveOccurred())
+	tree, err := commit.Tree()
+	g.Expect(err).ToNot(HaveOccurred())
+	files := map[string]string{}
+	g.Expect(tree.Files().ForEach(func(f *object.File) error {
+		c, err := f.Contents()
+		files[f.Name] = c
+		return err
+	})).To(Succeed())
+	g.Expect(files).To(Equal(map[string]string{"renamed/file": "to be renamed"}))
+
+	clean, err := ggc.IsClean()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(clean).To(BeTrue())
+
+	// Deleting a file which does not exist fails.
+	_, err = ggc.Commit(git.Commit{Author: author, Message: "delete missing file"},
+		repository.WithDeletedFiles([]string{"delete"}),
+	)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("unable to delete 'delete'"))
+}
+
+func TestCommit_renamedFilesOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		renames map[string]string
+		wantErr string
+	}{
+		{
+			name:    "swapped renames",
+			renames: map[string]string{"a": "b", "b": "a"},
+			wantErr: "unable to rename 'a' to 'b': 'b' is renamed as well",
+		},
+		{
+			name:    "chained renames",
+			renames: map[string]string{"a": "b", "b": "c"},
+			wantErr: "unable to rename 'a' to 'b': 'b' is renamed as well",
+		},
+		{
+			name:    "chained renames of unclean paths",
+			renames: map[string]string{"a": "./b", "b": "c"},
+			wantErr: "unable to rename 'a' to './b': 'b' is renamed as well",
+		},
+		{
+			name:    "renames to the same name",
+			renames: map[string]string{"a": "c", "b": "c"},
+			wantErr: "unable to rename 'b' to 'c': 'a' is renamed to it as well",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := t.TempDir()
+			repo, err := extgogit.PlainInit(path, false)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ggc, err := NewClient(path, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			ggc.repository = repo
+
+			author := git.Signature{
+				Name:  "Test User",
+				Email: "test@example.com",
+			}
+			_, err = ggc.Commit(git.Commit{Author: author, Message: "add files"},
+				repository.WithFiles(map[string]io.Reader{
+					"a": strings.NewReader("a"),
+					"b": strings.NewReader("b"),
+				}),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = ggc.Commit(git.Commit{Author: author, Message: "rename files"},
+				repository.WithRenamedFiles(tt.renames),
+			)
+			g.Expect(err).To(MatchError(tt.wantErr))
+
+			// The worktree is left untouched.
+			clean, err := ggc.IsClean()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(clean).To(BeTrue())
+		})
+	}
+}
+
+func TestCommit_committer(t *testing.T) {
+	tests := []struct {
+		name          string
+		committer     git.Signature
+		wantCommitter git.Signature
+	}{
+		{
+			name: "committer defaults to author",
+			wantCommitter: git.Signature{
+				Name:  "Test User",
+				Email: "test@example.com",
+			},
+		},
+		{
+			name: "committer separate from author",
+			committer: git.Signature{
+				Name:  "Flux Bot",
+				Email: "flux@example.com",
+			},
+			wantCommitter: git.Signature{
+				Name:  "Flux Bot",
+				Email: "flux@example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			path := t.TempDir()
+			repo, err := extgogit.PlainInit(path, false)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ggc, err := NewClient(path, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			ggc.repository = repo
+
+			cc, err := ggc.Commit(
+				git.Commit{
+					Author: git.Signature{
+						Name:  "Test User",
+						Email: "test@example.com",
+					},
+					Committer: tt.committer,
+					Message:   "testing",
+				},
+				repository.WithFiles(map[string]io.Reader{
+					"test": strings.NewReader("testing gogit committer"),
+				}),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			commit, err := repo.CommitObject(plumbing.NewHash(cc))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(commit.Author.Name).To(Equal("Test User"))
+			g.Expect(commit.Author.Email).To(Equal("test@example.com"))
+			g.Expect(commit.Committer.Name).To(Equal(tt.wantCommitter.Name))
+			g.Expect(commit.Committer.Email).To(Equal(tt.wantCommitter.Email))
+		})
+	}
+}
+
+func TestVerifyCommitAndTag(t *testing.T) {
+	g := NewWithT(t)
+
+	signer, err := openpgp.NewEntity("Test User", "", "test@example.com", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	other, err := openpgp.NewEntity("Other User", "", "other@example.com", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	path := t.TempDir()
+	repo, err := extgogit.PlainInit(path, false)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(path, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	cc, err := ggc.Commit(
+		git.Commit{
+			Author: git.Signature{
+				Name:  "Test User",
+				Email: "test@example.com",
+			},
+			Message: "signed",
+		},
+		repository.WithFiles(map[string]io.Reader{
+			"test": strings.NewReader("testing gogit verify"),
+		}),
+		repository.WithSigner(signer),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = repo.CreateTag("v0.1.0", plumbing.NewHash(cc), &extgogit.CreateTagOptions{
+		Tagger:  mockSignature(time.Now()),
+		Message: "signed tag",
+		SignKey: signer,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = repo.CreateTag("v0.2.0", plumbing.NewHash(cc), nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	keyRing := armoredKeyRing(t, signer)
+	otherKeyRing := armoredKeyRing(t, other)
+
+	id, err := ggc.VerifyCommit(cc, otherKeyRing, keyRing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(signer.PrimaryKey.KeyIdString()))
+
+	id, err = ggc.VerifyCommit("HEAD", keyRing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(signer.PrimaryKey.KeyIdString()))
+
+	_, err = ggc.VerifyCommit(cc, otherKeyRing)
+	g.Expect(err).To(MatchError(ContainSubstring("unable to verify Git commit")))
+
+	id, err = ggc.VerifyTag("v0.1.0", keyRing)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(id).To(Equal(signer.PrimaryKey.KeyIdString()))
+
+	_, err = ggc.VerifyTag("v0.1.0", otherKeyRing)
+	g.Expect(err).To(MatchError(ContainSubstring("unable to verify Git tag")))
+
+	_, err = ggc.VerifyTag("v0.2.0", keyRing)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func armoredKeyRing(t *testing.T, e *openpgp.Entity) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
 func TestPush(t *testing.T) {
 	g := NewWithT(t)
 
@@ -327,6 +710,162 @@ func TestPush_pushConfig_refspecs(t *testing.T) {
 	g.Expect(err).To(HaveOccurred())
 }
 
+func TestPush_pushConfig_mirror(t *testing.T) {
+	g := NewWithT(t)
+
+	server, repoURL, err := setupGitServer(false)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+	defer server.StopHTTP()
+
+	tmp := t.TempDir()
+	repo, err := extgogit.PlainClone(tmp, false, &extgogit.CloneOptions{
+		URL:        repoURL,
+		RemoteName: git.DefaultRemote,
+		Tags:       extgogit.NoTags,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(tmp, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	head, err := repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = tag(repo, head.Hash(), false, "v0.1.0", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	wt, err := repo.Worktree()
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, branch := range []string{"feature/mirror", "stale"} {
+		err = wt.Checkout(&extgogit.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(branch),
+			Create: true,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	headOnFeature, err := commitFile(repo, "test", "testing it on stale", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = tag(repo, headOnFeature, true, "v0.2.0", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	mirrorPath := t.TempDir()
+	mirror, err := extgogit.PlainInit(mirrorPath, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The authentication options for the remote URL are validated.
+	err = ggc.Push(context.TODO(), repository.PushConfig{
+		RemoteURL: "http://example.com/mirror.git",
+		AuthOptions: &git.AuthOptions{
+			Transport: git.HTTP,
+			Username:  "test-user",
+			Password:  "test-pass",
+		},
+		Mirror: true,
+	})
+	g.Expect(err).To(MatchError("basic auth cannot be sent over HTTP"))
+
+	err = ggc.Push(context.TODO(), repository.PushConfig{
+		RemoteURL: mirrorPath,
+		Mirror:    true,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Remove a branch locally, and check that the mirror push prunes it
+	// from the remote.
+	err = wt.Checkout(&extgogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature/mirror"),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(repo.Storer.RemoveReference(plumbing.NewBranchReferenceName("stale"))).To(Succeed())
+
+	err = ggc.Push(context.TODO(), repository.PushConfig{
+		RemoteURL: mirrorPath,
+		Mirror:    true,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	refs, err := mirror.References()
+	g.Expect(err).ToNot(HaveOccurred())
+	got := map[string]string{}
+	g.Expect(refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			got[ref.Name().String()] = ref.Hash().String()
+		}
+		return nil
+	})).To(Succeed())
+
+	v2, err := repo.Reference(plumbing.NewTagReferenceName("v0.2.0"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(map[string]string{
+		"refs/heads/master":         head.Hash().String(),
+		"refs/heads/feature/mirror": head.Hash().String(),
+		"refs/tags/v0.1.0":          head.Hash().String(),
+		"refs/tags/v0.2.0":          v2.Hash().String(),
+	}))
+
+	// The push to the mirror must not have changed origin, nor its
+	// remote-tracking references.
+	_, err = repo.Reference(plumbing.NewRemoteReferenceName(extgogit.DefaultRemoteName, "feature/mirror"), true)
+	g.Expect(err).To(HaveOccurred())
+	remote, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL: repoURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = remote.Reference(plumbing.NewRemoteReferenceName(extgogit.DefaultRemoteName, "feature/mirror"), true)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPush_pushConfig_mirrorSingleBranch(t *testing.T) {
+	g := NewWithT(t)
+
+	server, repoURL, err := setupGitServer(false)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+	defer server.StopHTTP()
+
+	// Add another branch to origin.
+	repo, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL: repoURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	head, err := repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("other"), head.Hash()))
+	g.Expect(err).ToNot(HaveOccurred())
+	err = repo.Push(&extgogit.PushOptions{
+		RefSpecs: []config.RefSpec{"refs/heads/other:refs/heads/other"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tmp := t.TempDir()
+	repo, err = extgogit.PlainClone(tmp, false, &extgogit.CloneOptions{
+		URL:           repoURL,
+		ReferenceName: plumbing.NewBranchReferenceName(git.DefaultBranch),
+		SingleBranch:  true,
+		Depth:         1,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(tmp, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	// A mirror push to origin would remove all the branches which are not
+	// in the clone.
+	err = ggc.Push(context.TODO(), repository.PushConfig{
+		Mirror: true,
+	})
+	g.Expect(err).To(MatchError("unable to mirror push without a remote URL"))
+
+	remote, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL: repoURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = remote.Reference(plumbing.NewRemoteReferenceName(extgogit.DefaultRemoteName, "other"), true)
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
 func TestForcePush(t *testing.T) {
 	g := NewWithT(t)
 
@@ -392,6 +931,233 @@ func TestForcePush(t *testing.T) {
 	g.Expect(ref.Hash().String()).To(Equal(cc2.String()))
 }
 
+func TestForcePush_withLease(t *testing.T) {
+	g := NewWithT(t)
+
+	server, repoURL, err := setupGitServer(false)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+	defer server.StopHTTP()
+
+	tmp1 := t.TempDir()
+	repo1, err := extgogit.PlainClone(tmp1, false, &extgogit.CloneOptions{
+		URL:        repoURL,
+		RemoteName: git.DefaultRemote,
+		Tags:       extgogit.NoTags,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc1, err := NewClient(tmp1, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc1.repository = repo1
+
+	tmp2 := t.TempDir()
+	repo2, err := extgogit.PlainClone(tmp2, false, &extgogit.CloneOptions{
+		URL:        repoURL,
+		RemoteName: git.DefaultRemote,
+		Tags:       extgogit.NoTags,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc2, err := NewClient(tmp2, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc2.repository = repo2
+
+	cc1, err := commitFile(repo1, "test", "first push", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	err = ggc1.Push(context.TODO(), repository.PushConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The lease of ggc2 is stale, as its remote-tracking reference does not
+	// contain the commit pushed by ggc1.
+	cc2, err := commitFile(repo2, "test", "diverged push", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	err = ggc2.Push(context.TODO(), repository.PushConfig{
+		ForceWithLease: true,
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("non-fast-forward update")))
+
+	repo, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL: repoURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	ref, err := repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ref.Hash().String()).To(Equal(cc1.String()))
+
+	// After fetching, the lease is up to date and the diverged branch
+	// is force pushed.
+	err = ggc2.Fetch(context.TODO(), repository.FetchConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	err = ggc2.Push(context.TODO(), repository.PushConfig{
+		ForceWithLease: true,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	repo, err = extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL: repoURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	ref, err = repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ref.Hash().String()).To(Equal(cc2.String()))
+
+	err = ggc2.Push(context.TODO(), repository.PushConfig{
+		RemoteURL:      repoURL,
+		ForceWithLease: true,
+	})
+	g.Expect(err).To(MatchError("unable to force push with lease to a remote URL"))
+}
+
+func TestFetch(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      repository.FetchConfig
+		wantTags bool
+	}{
+		{
+			name: "fetch with the remote refspecs",
+		},
+		{
+			name: "fetch with refspecs",
+			cfg: repository.FetchConfig{
+				Refspecs: []string{"+refs/heads/*:refs/remotes/origin/*"},
+			},
+		},
+		{
+			name:     "fetch with tags",
+			cfg:      repository.FetchConfig{Tags: true},
+			wantTags: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server, repoURL, err := setupGitServer(false)
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(server.Root())
+			defer server.StopHTTP()
+
+			tmp := t.TempDir()
+			repo, err := extgogit.PlainClone(tmp, false, &extgogit.CloneOptions{
+				URL:        repoURL,
+				RemoteName: git.DefaultRemote,
+				Tags:       extgogit.NoTags,
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			head, err := repo.Head()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ggc, err := NewClient(tmp, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			ggc.repository = repo
+
+			// Commit to the default branch and a new branch upstream.
+			upstream, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+				URL:        repoURL,
+				RemoteName: git.DefaultRemote,
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			mainCC, err := commitFile(upstream, "test", "testing gogit fetch", time.Now())
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = tag(upstream, mainCC, false, "v0.1.0", time.Now())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(createBranch(upstream, "new")).To(Succeed())
+			newCC, err := commitFile(upstream, "test", "testing gogit fetch on new branch", time.Now())
+			g.Expect(err).ToNot(HaveOccurred())
+			err = upstream.Push(&extgogit.PushOptions{
+				RemoteName: git.DefaultRemote,
+				RefSpecs: []config.RefSpec{
+					"refs/heads/*:refs/heads/*",
+					"refs/tags/*:refs/tags/*",
+				},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			err = ggc.Fetch(context.TODO(), tt.cfg)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ref, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemote, git.DefaultBranch), true)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ref.Hash()).To(Equal(mainCC))
+			ref, err = repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemote, "new"), true)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ref.Hash()).To(Equal(newCC))
+
+			_, err = repo.Reference(plumbing.NewTagReferenceName("v0.1.0"), true)
+			if tt.wantTags {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(plumbing.ErrReferenceNotFound))
+			}
+
+			// The worktree must be left untouched.
+			localHead, err := repo.Head()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(localHead.Hash()).To(Equal(head.Hash()))
+
+			// Fetching again is a no-op.
+			g.Expect(ggc.Fetch(context.TODO(), tt.cfg)).To(Succeed())
+		})
+	}
+}
+
+func TestFetch_prune(t *testing.T) {
+	g := NewWithT(t)
+
+	server, repoURL, err := setupGitServer(false)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+	defer server.StopHTTP()
+
+	upstream, err := extgogit.PlainClone(t.TempDir(), false, &extgogit.CloneOptions{
+		URL:        repoURL,
+		RemoteName: git.DefaultRemote,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(createBranch(upstream, "stale")).To(Succeed())
+	_, err = commitFile(upstream, "test", "testing gogit fetch prune", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	err = upstream.Push(&extgogit.PushOptions{
+		RemoteName: git.DefaultRemote,
+		RefSpecs:   []config.RefSpec{"refs/heads/stale:refs/heads/stale"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tmp := t.TempDir()
+	repo, err := extgogit.PlainClone(tmp, false, &extgogit.CloneOptions{
+		URL:        repoURL,
+		RemoteName: git.DefaultRemote,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	staleRef := plumbing.NewRemoteReferenceName(git.DefaultRemote, "stale")
+	_, err = repo.Reference(staleRef, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(tmp, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	// Delete the branch upstream.
+	err = upstream.Push(&extgogit.PushOptions{
+		RemoteName: git.DefaultRemote,
+		RefSpecs:   []config.RefSpec{":refs/heads/stale"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = ggc.Fetch(context.TODO(), repository.FetchConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = repo.Reference(staleRef, true)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = ggc.Fetch(context.TODO(), repository.FetchConfig{Prune: true})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = repo.Reference(staleRef, true)
+	g.Expect(err).To(MatchError(plumbing.ErrReferenceNotFound))
+}
+
 func TestSwitchBranch(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -399,6 +1165,8 @@ func TestSwitchBranch(t *testing.T) {
 		changeRepo   func(g *WithT, c *Client) string
 		branch       string
 		singleBranch bool
+		mode         repository.SwitchBranchMode
+		wantErr      error
 	}{
 		{
 			name: "switch to a branch ahead of the current branch",
@@ -537,6 +1305,38 @@ func TestSwitchBranch(t *testing.T) {
 			setupFunc: nil,
 			branch:    "new",
 		},
+		{
+			name: "strict: switch to a branch that exists in the remote",
+			setupFunc: func(g *WithT, repoURL string) string {
+				tmp := t.TempDir()
+				repo, err := extgogit.PlainClone(tmp, false, &extgogit.CloneOptions{
+					URL:           repoURL,
+					ReferenceName: plumbing.NewBranchReferenceName(git.DefaultBranch),
+					RemoteName:    git.DefaultRemote,
+				})
+				g.Expect(err).ToNot(HaveOccurred())
+
+				err = createBranch(repo, "existing")
+				g.Expect(err).ToNot(HaveOccurred())
+
+				cc, err := commitFile(repo, "test", "testing gogit strict switch", time.Now())
+				g.Expect(err).ToNot(HaveOccurred())
+				err = repo.Push(&extgogit.PushOptions{
+					RemoteName: git.DefaultRemote,
+				})
+				g.Expect(err).ToNot(HaveOccurred())
+				return cc.String()
+			},
+			branch: "existing",
+			mode:   repository.SwitchBranchStrict,
+		},
+		{
+			name:      "strict: fail to switch to a branch that doesn't exist",
+			setupFunc: nil,
+			branch:    "new",
+			mode:      repository.SwitchBranchStrict,
+			wantErr:   git.ErrBranchNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -576,7 +1376,21 @@ func TestSwitchBranch(t *testing.T) {
 				expectedHash = tt.changeRepo(g, ggc)
 			}
 
-			err = ggc.SwitchBranch(context.TODO(), tt.branch)
+			var opts []repository.SwitchBranchOption
+			if tt.mode != "" {
+				opts = append(opts, repository.WithSwitchBranchMode(tt.mode))
+			}
+			err = ggc.SwitchBranch(context.TODO(), tt.branch, opts...)
+			if tt.wantErr != nil {
+				g.Expect(err).To(MatchError(tt.wantErr))
+
+				// The current branch must be left untouched.
+				ref, err := ggc.repository.Head()
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(ref.Name().Short()).To(Equal(git.DefaultBranch))
+				g.Expect(ref.Hash().String()).To(Equal(expectedHash))
+				return
+			}
 			g.Expect(err).ToNot(HaveOccurred())
 
 			ref, err := ggc.repository.Head()
@@ -587,6 +1401,52 @@ func TestSwitchBranch(t *testing.T) {
 	}
 }
 
+func TestBearerTokenAuth(t *testing.T) {
+	g := NewWithT(t)
+
+	server, err := gittestserver.NewTempGitServer()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(server.Root())
+
+	var authHeaders []string
+	server.AddHTTPMiddlewares(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+			next.ServeHTTP(w, r)
+		})
+	})
+	err = server.InitRepo("../testdata/git/repo", git.DefaultBranch, "test.git")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(server.StartHTTP()).To(Succeed())
+	defer server.StopHTTP()
+
+	ggc, err := NewClient(t.TempDir(), &git.AuthOptions{
+		Transport:   git.HTTP,
+		BearerToken: "token",
+	}, WithDiskStorage(), WithInsecureCredentialsOverHTTP())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = ggc.Clone(context.TODO(), server.HTTPAddress()+"/test.git", repository.CloneConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ggc.Fetch(context.TODO(), repository.FetchConfig{})).To(Succeed())
+	_, err = ggc.Commit(git.Commit{
+		Author: git.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+		},
+		Message: "testing",
+	}, repository.WithFiles(map[string]io.Reader{
+		"test": strings.NewReader("testing gogit bearer token auth"),
+	}))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ggc.Push(context.TODO(), repository.PushConfig{})).To(Succeed())
+
+	g.Expect(authHeaders).ToNot(BeEmpty())
+	for _, h := range authHeaders {
+		g.Expect(h).To(Equal("Bearer token"))
+	}
+}
+
 func TestIsClean(t *testing.T) {
 	g := NewWithT(t)
 
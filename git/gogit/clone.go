@@ -84,6 +84,7 @@ func (g *Client) cloneBranch(ctx context.Context, url, branch string, opts repos
 		Progress:          nil,
 		Tags:              extgogit.NoTags,
 		CABundle:          caBundle(g.authOpts),
+		InsecureSkipTLS:   g.insecureSkipTLS,
 		ProxyOptions:      g.proxy,
 	}
 
@@ -166,9 +167,10 @@ func (g *Client) cloneTag(ctx context.Context, url, tag string, opts repository.
 		RecurseSubmodules: recurseSubmodules(opts.RecurseSubmodules),
 		Progress:          nil,
 		// Ask for the tag object that points to the commit to be sent as well.
-		Tags:         extgogit.TagFollowing,
-		CABundle:     caBundle(g.authOpts),
-		ProxyOptions: g.proxy,
+		Tags:            extgogit.TagFollowing,
+		CABundle:        caBundle(g.authOpts),
+		InsecureSkipTLS: g.insecureSkipTLS,
+		ProxyOptions:    g.proxy,
 	}
 
 	repo, err := extgogit.CloneContext(ctx, g.storer, g.worktreeFS, cloneOpts)
@@ -227,6 +229,7 @@ func (g *Client) cloneCommit(ctx context.Context, url, commit string, opts repos
 		Progress:          nil,
 		Tags:              tagStrategy,
 		CABundle:          caBundle(g.authOpts),
+		InsecureSkipTLS:   g.insecureSkipTLS,
 		ProxyOptions:      g.proxy,
 	}
 	if opts.Branch != "" {
@@ -294,6 +297,7 @@ func (g *Client) cloneSemVer(ctx context.Context, url, semverTag string, opts re
 		Progress:          nil,
 		Tags:              extgogit.AllTags,
 		CABundle:          caBundle(g.authOpts),
+		InsecureSkipTLS:   g.insecureSkipTLS,
 		ProxyOptions:      g.proxy,
 	}
 
@@ -470,10 +474,11 @@ func (g *Client) getRemoteHEAD(ctx context.Context, url string, ref plumbing.Ref
 	}
 	remote := extgogit.NewRemote(memory.NewStorage(), remoteCfg)
 	listOpts := &extgogit.ListOptions{
-		Auth:          authMethod,
-		CABundle:      caBundle(g.authOpts),
-		PeelingOption: extgogit.AppendPeeled,
-		ProxyOptions:  g.proxy,
+		Auth:            authMethod,
+		CABundle:        caBundle(g.authOpts),
+		InsecureSkipTLS: g.insecureSkipTLS,
+		PeelingOption:   extgogit.AppendPeeled,
+		ProxyOptions:    g.proxy,
 	}
 	refs, err := remote.ListContext(ctx, listOpts)
 	if err != nil {
@@ -18,6 +18,7 @@ package gogit
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	iofs "io/fs"
@@ -181,7 +182,9 @@ func TestClone_cloneBranch(t *testing.T) {
 			g.Expect(cc.String()).To(Equal(tt.branch + "@" + git.HashTypeSHA1 + ":" + tt.expectedCommit))
 			g.Expect(git.IsConcreteCommit(*cc)).To(Equal(tt.expectedConcreteCommit))
 
+			g.Expect(cc.Reference).To(Equal(plumbing.NewBranchReferenceName(tt.branch).String()))
 			if tt.expectedConcreteCommit {
+				g.Expect(cc.ShortMessage()).To(Equal("Adding: branch"))
 				for k, v := range tt.filesCreated {
 					g.Expect(filepath.Join(tmpDir, k)).To(BeARegularFile())
 					g.Expect(os.ReadFile(filepath.Join(tmpDir, k))).To(BeEquivalentTo(v))
@@ -340,6 +343,7 @@ func TestClone_cloneCommit(t *testing.T) {
 		name         string
 		commit       string
 		branch       string
+		refName      string
 		expectCommit string
 		expectFile   string
 		expectError  string
@@ -357,6 +361,13 @@ func TestClone_cloneCommit(t *testing.T) {
 			expectCommit: "other-branch@" + git.HashTypeSHA1 + ":" + secondCommit.String(),
 			expectFile:   "second",
 		},
+		{
+			name:         "Commit with reference name hint",
+			commit:       firstCommit.String(),
+			refName:      "refs/heads/other-branch",
+			expectCommit: "other-branch@" + git.HashTypeSHA1 + ":" + firstCommit.String(),
+			expectFile:   "init",
+		},
 		{
 			name:        "Non existing commit",
 			commit:      "a-random-invalid-commit",
@@ -376,8 +387,9 @@ func TestClone_cloneCommit(t *testing.T) {
 			tmpDir := t.TempDir()
 			opts := repository.CloneConfig{
 				CheckoutStrategy: repository.CheckoutStrategy{
-					Branch: tt.branch,
-					Commit: tt.commit,
+					Branch:  tt.branch,
+					Commit:  tt.commit,
+					RefName: tt.refName,
 				},
 				ShallowClone: true,
 			}
@@ -395,12 +407,122 @@ func TestClone_cloneCommit(t *testing.T) {
 			g.Expect(err).ToNot(HaveOccurred())
 			g.Expect(cc).ToNot(BeNil())
 			g.Expect(cc.String()).To(Equal(tt.expectCommit))
+			if tt.refName != "" {
+				g.Expect(cc.Reference).To(Equal(tt.refName))
+			}
 			g.Expect(filepath.Join(tmpDir, "commit")).To(BeARegularFile())
 			g.Expect(os.ReadFile(filepath.Join(tmpDir, "commit"))).To(BeEquivalentTo(tt.expectFile))
 		})
 	}
 }
 
+func TestContentDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"file":         "content",
+		"dir/file":     "nested content",
+		".git/HEAD":    "excluded",
+		"dir/.git":     "excluded",
+		"dir/sub/file": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		g.Expect(os.MkdirAll(filepath.Dir(p), 0o755)).To(Succeed())
+		g.Expect(os.WriteFile(p, []byte(content), 0o644)).To(Succeed())
+	}
+	g.Expect(os.Symlink("file", filepath.Join(dir, "link"))).To(Succeed())
+
+	sum := func(s string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	}
+	manifest := sum("nested content") + "  dir/file\n" +
+		sum("") + "  dir/sub/file\n" +
+		sum("content") + "  file\n" +
+		sum("file") + "  link\n"
+
+	digest, err := ContentDigest(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(digest).To(Equal("sha256:" + sum(manifest)))
+}
+
+func TestClone_contentDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	repo, path, err := initRepo(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = commitFile(repo, "file", "content", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	head, err := commitFile(repo, "dir/file", "nested content", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Compute the digest of a clone stored on disk, which has the .git
+	// directory in its worktree.
+	tmpDir := t.TempDir()
+	ggc, err := NewClient(tmpDir, &git.AuthOptions{Transport: git.HTTP})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = ggc.Clone(context.TODO(), path, repository.CloneConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	digest, err := ContentDigest(tmpDir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(digest).To(HavePrefix(contentDigestPrefix))
+
+	tests := []struct {
+		name        string
+		digest      string
+		checkout    repository.CheckoutStrategy
+		expectError string
+	}{
+		{
+			name:   "matching digest for branch",
+			digest: digest,
+		},
+		{
+			name:     "matching digest for commit",
+			digest:   digest,
+			checkout: repository.CheckoutStrategy{Commit: head.String()},
+		},
+		{
+			name:        "mismatching digest",
+			digest:      contentDigestPrefix + "0000",
+			expectError: "content digest mismatch: expected 'sha256:0000', got '" + digest + "'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Clone into memory, which has no .git directory in its worktree.
+			ggc, err := NewClient(t.TempDir(), &git.AuthOptions{Transport: git.HTTP}, WithMemoryStorage())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			cc, err := ggc.Clone(context.TODO(), path, repository.CloneConfig{
+				CheckoutStrategy:      tt.checkout,
+				ExpectedContentDigest: tt.digest,
+			})
+			if tt.expectError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.expectError))
+				g.Expect(cc).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cc.Hash.String()).To(Equal(head.String()))
+		})
+	}
+
+	t.Run("tampered worktree", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(ggc.verifyContentDigest(digest)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(tmpDir, "dir", "file"), []byte("tampered"), 0o600)).To(Succeed())
+		err := ggc.verifyContentDigest(digest)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("content digest mismatch"))
+	})
+}
+
 func TestClone_cloneSemVer(t *testing.T) {
 	now := time.Now()
 
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gogit
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	extgogit "github.com/go-git/go-git/v5"
+)
+
+const contentDigestPrefix = "sha256:"
+
+// ContentDigest returns the digest of the content of the worktree at the
+// given path, as verified by a clone with
+// repository.CloneConfig.ExpectedContentDigest set.
+//
+// The digest is in the format of "sha256:<hex>", where <hex> is the SHA-256
+// of a manifest with a "<sum>  <path>\n" line for every file of the worktree,
+// sorted by path in byte order. <sum> is the hex encoded SHA-256 of the
+// content of the file, or of the target of a symbolic link, and <path> is
+// the slash separated path of the file relative to the worktree. Directories
+// only contribute through their files, and any entry named ".git" is
+// excluded together with its content. The digest is therefore independent
+// of timestamps and permissions.
+func ContentDigest(path string) (string, error) {
+	return contentDigest(osfs.New(path, osfs.WithBoundOS()))
+}
+
+// contentDigest returns the digest of the content of the given worktree
+// filesystem, see ContentDigest for its format.
+func contentDigest(fs billy.Filesystem) (string, error) {
+	sums := map[string][]byte{}
+	err := util.Walk(fs, ".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == extgogit.GitDirName {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		h := sha256.New()
+		switch {
+		case info.IsDir():
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := fs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if _, err = io.WriteString(h, target); err != nil {
+				return err
+			}
+		default:
+			f, err := fs.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		sums[filepath.ToSlash(path)] = h.Sum(nil)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%x  %s\n", sums[p], p)
+	}
+	return fmt.Sprintf("%s%x", contentDigestPrefix, h.Sum(nil)), nil
+}
+
+// verifyContentDigest compares the digest of the content of the worktree
+// with the expected digest, and returns an error if they do not match.
+func (g *Client) verifyContentDigest(expected string) error {
+	actual, err := contentDigest(g.worktreeFS)
+	if err != nil {
+		return fmt.Errorf("unable to compute content digest: %w", err)
+	}
+	if actual != expected {
+		return fmt.Errorf("content digest mismatch: expected '%s', got '%s'", expected, actual)
+	}
+	return nil
+}
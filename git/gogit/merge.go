@@ -0,0 +1,314 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	extgogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/repository"
+)
+
+// treeFile is a non-directory entry of a Git tree.
+type treeFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// Merge merges otherRef into the current HEAD by creating a merge commit
+// with HEAD as its first parent and otherRef as its second parent. The
+// branch HEAD points to, and the worktree, are updated to the merge commit.
+// otherRef can be any revision that resolves to a commit, for example
+// "refs/remotes/origin/feature" or a commit hash.
+//
+// Files changed on only one side since the merge base are taken from that
+// side. Files changed on both sides in different ways are conflicts, which
+// are resolved according to the configured repository.MergeStrategy. With
+// the default strategy, git.ErrMergeConflict is returned and the repository
+// is left untouched.
+//
+// NOTE: Unlike Git, the merge is performed per file rather than per line.
+// A file changed on both sides is a conflict even if the changes do not
+// overlap, and repository.MergeStrategyOurs and MergeStrategyTheirs resolve
+// it by taking the whole file from one side, dropping the changes made to
+// it on the other side.
+//
+// A merge commit is always created, even if HEAD could be fast-forwarded.
+// If otherRef is already reachable from HEAD, the hash of HEAD is returned
+// together with git.ErrAlreadyMerged.
+func (g *Client) Merge(ctx context.Context, otherRef string, info git.Commit, mergeOpts ...repository.MergeOption) (string, error) {
+	if g.repository == nil {
+		return "", git.ErrNoGitRepository
+	}
+
+	options := &repository.MergeOptions{
+		Strategy: repository.MergeStrategyFail,
+	}
+	for _, o := range mergeOpts {
+		o(options)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	wt, err := g.repository.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to load worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", err
+	}
+	if !status.IsClean() {
+		return "", errors.New("unable to merge: worktree contains uncommitted changes")
+	}
+
+	head, err := g.repository.Head()
+	if err != nil {
+		return "", err
+	}
+	headCommit, err := g.repository.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve commit object for HEAD '%s': %w", head.Hash(), err)
+	}
+	otherHash, err := g.repository.ResolveRevision(plumbing.Revision(otherRef))
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve '%s': %w", otherRef, err)
+	}
+	otherCommit, err := g.repository.CommitObject(*otherHash)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve commit object for '%s': %w", otherRef, err)
+	}
+
+	merged, err := otherCommit.IsAncestor(headCommit)
+	if err != nil {
+		return "", fmt.Errorf("unable to compare '%s' with HEAD: %w", otherRef, err)
+	}
+	if merged || otherCommit.Hash == headCommit.Hash {
+		return head.Hash().String(), git.ErrAlreadyMerged
+	}
+
+	// Unrelated histories are merged against an empty base.
+	baseFiles := map[string]treeFile{}
+	bases, err := headCommit.MergeBase(otherCommit)
+	if err != nil {
+		return "", fmt.Errorf("unable to find merge base of HEAD and '%s': %w", otherRef, err)
+	}
+	if len(bases) > 0 {
+		if baseFiles, err = commitFiles(ctx, bases[0]); err != nil {
+			return "", err
+		}
+	}
+	ourFiles, err := commitFiles(ctx, headCommit)
+	if err != nil {
+		return "", err
+	}
+	theirFiles, err := commitFiles(ctx, otherCommit)
+	if err != nil {
+		return "", err
+	}
+
+	files, conflicts := mergeFiles(baseFiles, ourFiles, theirFiles, options.Strategy)
+	if len(conflicts) > 0 {
+		return "", fmt.Errorf("%w: %s", git.ErrMergeConflict, strings.Join(conflicts, ", "))
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	treeHash, err := writeTree(g.repository.Storer, files)
+	if err != nil {
+		return "", fmt.Errorf("unable to write merge tree: %w", err)
+	}
+
+	author, committer := commitSignatures(info, time.Now())
+	commit := &object.Commit{
+		Author:       author,
+		Committer:    committer,
+		Message:      info.Message,
+		TreeHash:     treeHash,
+		ParentHashes: []plumbing.Hash{headCommit.Hash, otherCommit.Hash},
+	}
+	obj := g.repository.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return "", fmt.Errorf("unable to encode merge commit: %w", err)
+	}
+	hash, err := g.repository.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return "", fmt.Errorf("unable to store merge commit: %w", err)
+	}
+
+	refName := plumbing.HEAD
+	if head.Name().IsBranch() {
+		refName = head.Name()
+	}
+	if err := g.repository.Storer.SetReference(plumbing.NewHashReference(refName, hash)); err != nil {
+		return "", fmt.Errorf("unable to update '%s' to merge commit: %w", refName, err)
+	}
+	if err := wt.Reset(&extgogit.ResetOptions{
+		Commit: hash,
+		Mode:   extgogit.HardReset,
+	}); err != nil {
+		return "", fmt.Errorf("unable to update worktree to merge commit: %w", err)
+	}
+
+	return hash.String(), nil
+}
+
+// commitFiles returns all the non-directory entries of the tree of the
+// given commit, keyed by their path. It stops walking the tree when the
+// context is done.
+func commitFiles(ctx context.Context, c *object.Commit) (map[string]treeFile, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve tree of commit '%s': %w", c.Hash, err)
+	}
+
+	files := map[string]treeFile{}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to walk tree of commit '%s': %w", c.Hash, err)
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		files[name] = treeFile{hash: entry.Hash, mode: entry.Mode}
+	}
+	return files, nil
+}
+
+// mergeFiles performs a three-way merge of the given file sets on a per
+// file basis. It returns the merged files, and the paths which are in
+// conflict and could not be resolved using the strategy.
+func mergeFiles(base, ours, theirs map[string]treeFile, strategy repository.MergeStrategy) (map[string]treeFile, []string) {
+	paths := map[string]struct{}{}
+	for _, files := range []map[string]treeFile{base, ours, theirs} {
+		for p := range files {
+			paths[p] = struct{}{}
+		}
+	}
+
+	merged := map[string]treeFile{}
+	var conflicts []string
+	for p := range paths {
+		b, inBase := base[p]
+		o, inOurs := ours[p]
+		t, inTheirs := theirs[p]
+
+		switch {
+		case inOurs == inTheirs && o == t:
+			// Both sides made the same change, or none at all.
+			if inOurs {
+				merged[p] = o
+			}
+		case inBase == inTheirs && b == t:
+			// Only our side changed.
+			if inOurs {
+				merged[p] = o
+			}
+		case inBase == inOurs && b == o:
+			// Only their side changed.
+			if inTheirs {
+				merged[p] = t
+			}
+		default:
+			switch strategy {
+			case repository.MergeStrategyOurs:
+				if inOurs {
+					merged[p] = o
+				}
+			case repository.MergeStrategyTheirs:
+				if inTheirs {
+					merged[p] = t
+				}
+			default:
+				conflicts = append(conflicts, p)
+			}
+		}
+	}
+
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// writeTree writes the tree (and any subtrees) for the given files to the
+// storer, and returns the hash of the root tree.
+func writeTree(s storer.EncodedObjectStorer, files map[string]treeFile) (plumbing.Hash, error) {
+	var entries []object.TreeEntry
+	subtrees := map[string]map[string]treeFile{}
+	for p, f := range files {
+		if dir, rest, ok := strings.Cut(p, "/"); ok {
+			if subtrees[dir] == nil {
+				subtrees[dir] = map[string]treeFile{}
+			}
+			subtrees[dir][rest] = f
+			continue
+		}
+		entries = append(entries, object.TreeEntry{Name: p, Mode: f.mode, Hash: f.hash})
+	}
+	for dir, sub := range subtrees {
+		if _, ok := files[dir]; ok {
+			return plumbing.ZeroHash, fmt.Errorf("%w: '%s' is both a file and a directory", git.ErrMergeConflict, dir)
+		}
+		h, err := writeTree(s, sub)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		entries = append(entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: h})
+	}
+
+	// Git sorts tree entries by name, with directories compared as if
+	// their name had a trailing slash.
+	sortKey := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(entries[i]) < sortKey(entries[j])
+	})
+
+	tree := &object.Tree{Entries: entries}
+	obj := s.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(obj)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gogit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	extgogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/gomega"
+
+	"github.com/fluxcd/pkg/git"
+	"github.com/fluxcd/pkg/git/repository"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		ourChanges   map[string]string
+		theirChanges map[string]string
+		strategy     repository.MergeStrategy
+		wantFiles    map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "merge non-conflicting changes",
+			ourChanges:   map[string]string{"ours": "our change"},
+			theirChanges: map[string]string{"dir/theirs": "their change"},
+			wantFiles: map[string]string{
+				"base":       "base",
+				"ours":       "our change",
+				"dir/theirs": "their change",
+			},
+		},
+		{
+			name:         "merge identical changes",
+			ourChanges:   map[string]string{"base": "same change"},
+			theirChanges: map[string]string{"base": "same change", "theirs": "their change"},
+			wantFiles: map[string]string{
+				"base":   "same change",
+				"theirs": "their change",
+			},
+		},
+		{
+			name:         "conflict fails by default",
+			ourChanges:   map[string]string{"base": "our change"},
+			theirChanges: map[string]string{"base": "their change"},
+			wantErr:      git.ErrMergeConflict,
+		},
+		{
+			// Files are merged as a whole, rather than per line.
+			name:         "non-overlapping changes to the same file conflict",
+			ourChanges:   map[string]string{"base": "our line\nbase"},
+			theirChanges: map[string]string{"base": "base\ntheir line"},
+			wantErr:      git.ErrMergeConflict,
+		},
+		{
+			name:         "conflict resolved with ours strategy",
+			ourChanges:   map[string]string{"base": "our change"},
+			theirChanges: map[string]string{"base": "their change", "theirs": "their change"},
+			strategy:     repository.MergeStrategyOurs,
+			wantFiles: map[string]string{
+				"base":   "our change",
+				"theirs": "their change",
+			},
+		},
+		{
+			name:         "conflict resolved with theirs strategy",
+			ourChanges:   map[string]string{"base": "our change", "ours": "our change"},
+			theirChanges: map[string]string{"base": "their change"},
+			strategy:     repository.MergeStrategyTheirs,
+			wantFiles: map[string]string{
+				"base": "their change",
+				"ours": "our change",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			repo, path, err := initRepo(t.TempDir())
+			g.Expect(err).ToNot(HaveOccurred())
+			_, err = commitFile(repo, "base", "base", time.Now())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(createBranch(repo, "feature")).To(Succeed())
+			for f, c := range tt.theirChanges {
+				_, err = commitFile(repo, f, c, time.Now())
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			theirs, err := repo.Head()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			wt, err := repo.Worktree()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(wt.Checkout(&extgogit.CheckoutOptions{Branch: plumbing.Master})).To(Succeed())
+			for f, c := range tt.ourChanges {
+				_, err = commitFile(repo, f, c, time.Now())
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			ours, err := repo.Head()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			ggc, err := NewClient(path, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			ggc.repository = repo
+
+			var opts []repository.MergeOption
+			if tt.strategy != "" {
+				opts = append(opts, repository.WithMergeStrategy(tt.strategy))
+			}
+			hash, err := ggc.Merge(context.TODO(), "refs/heads/feature", git.Commit{
+				Author: git.Signature{
+					Name:  "Test User",
+					Email: "test@example.com",
+				},
+				Message: "Merge feature",
+			}, opts...)
+			if tt.wantErr != nil {
+				g.Expect(err).To(MatchError(tt.wantErr))
+				head, err := repo.Head()
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(head.Hash()).To(Equal(ours.Hash()))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			head, err := repo.Head()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(head.Name()).To(Equal(plumbing.Master))
+			g.Expect(head.Hash().String()).To(Equal(hash))
+
+			commit, err := repo.CommitObject(head.Hash())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(commit.Message).To(Equal("Merge feature"))
+			g.Expect(commit.ParentHashes).To(Equal([]plumbing.Hash{ours.Hash(), theirs.Hash()}))
+
+			tree, err := commit.Tree()
+			g.Expect(err).ToNot(HaveOccurred())
+			files := map[string]string{}
+			g.Expect(tree.Files().ForEach(func(f *object.File) error {
+				c, err := f.Contents()
+				files[f.Name] = c
+				return err
+			})).To(Succeed())
+			g.Expect(files).To(Equal(tt.wantFiles))
+
+			clean, err := ggc.IsClean()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(clean).To(BeTrue())
+		})
+	}
+}
+
+func TestMerge_alreadyMerged(t *testing.T) {
+	g := NewWithT(t)
+
+	repo, path, err := initRepo(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = commitFile(repo, "base", "base", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(createBranch(repo, "feature")).To(Succeed())
+	cc, err := commitFile(repo, "feature", "feature", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(path, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	hash, err := ggc.Merge(context.TODO(), plumbing.Master.String(), git.Commit{})
+	g.Expect(err).To(MatchError(git.ErrAlreadyMerged))
+	g.Expect(hash).To(Equal(cc.String()))
+}
+
+func TestMerge_canceledContext(t *testing.T) {
+	g := NewWithT(t)
+
+	repo, path, err := initRepo(t.TempDir())
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = commitFile(repo, "base", "base", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(createBranch(repo, "feature")).To(Succeed())
+	_, err = commitFile(repo, "feature", "feature", time.Now())
+	g.Expect(err).ToNot(HaveOccurred())
+	wt, err := repo.Worktree()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(wt.Checkout(&extgogit.CheckoutOptions{Branch: plumbing.Master})).To(Succeed())
+	ours, err := repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ggc, err := NewClient(path, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	ggc.repository = repo
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = ggc.Merge(ctx, "refs/heads/feature", git.Commit{})
+	g.Expect(err).To(MatchError(context.Canceled))
+
+	head, err := repo.Head()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(head.Hash()).To(Equal(ours.Hash()))
+}
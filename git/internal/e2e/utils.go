@@ -50,8 +50,9 @@ func testUsingClone(g *WithT, client repository.Client, repoURL *url.URL, upstre
 	//
 	// NB: It may take some time for any deploy keys to be actually propagated
 	// to the backing Git provider, so we retry for a fixed amount of time.
-	g.Eventually(func() error {
-		_, err := client.Clone(context.TODO(), repoURL.String(), repository.CloneConfig{
+	var cloned *git.Commit
+	g.Eventually(func() (err error) {
+		cloned, err = client.Clone(context.TODO(), repoURL.String(), repository.CloneConfig{
 			CheckoutStrategy: repository.CheckoutStrategy{
 				Branch: "main",
 			},
@@ -59,6 +60,13 @@ func testUsingClone(g *WithT, client repository.Client, repoURL *url.URL, upstre
 		return err
 	}, timeout).Should(Succeed())
 
+	// The cloned commit must match the upstream HEAD.
+	headCommit, _, err := headCommitWithBranch(upstreamRepo.url, "main", upstreamRepo.username, upstreamRepo.password)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cloned).ToNot(BeNil())
+	g.Expect(cloned.Hash.String()).To(Equal(headCommit))
+	g.Expect(cloned.Reference).To(Equal("refs/heads/main"))
+
 	// Commit a change.
 	cc, err := client.Commit(
 		mockCommitInfo(),
@@ -80,7 +88,7 @@ func testUsingClone(g *WithT, client repository.Client, repoURL *url.URL, upstre
 		return client.Push(context.TODO(), repository.PushConfig{})
 	}, timeout).Should(Succeed())
 
-	headCommit, _, err := headCommitWithBranch(upstreamRepo.url, "main", upstreamRepo.username, upstreamRepo.password)
+	headCommit, _, err = headCommitWithBranch(upstreamRepo.url, "main", upstreamRepo.username, upstreamRepo.password)
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(headCommit).To(Equal(cc))
 
@@ -27,7 +27,8 @@ import (
 type Reader interface {
 	// Clone clones a repository from the provided url using the config provided.
 	// It returns a Commit object describing the Git commit that the repository
-	// HEAD points to. If the repository is empty, it returns a nil Commit.
+	// HEAD points to, including its hash, the reference it was resolved from
+	// and its message. If the repository is empty, it returns a nil Commit.
 	Clone(ctx context.Context, url string, cfg CloneConfig) (*git.Commit, error)
 	// IsClean returns whether the working tree is clean.
 	IsClean() (bool, error)
@@ -49,8 +50,9 @@ type Writer interface {
 	// the origin, but this is configurable via PushConfig.
 	Push(ctx context.Context, cfg PushConfig) error
 	// SwitchBranch switches from the current branch of the repository to the
-	// provided branch. If the branch doesn't exist, it is created.
-	SwitchBranch(ctx context.Context, branch string) error
+	// provided branch. If the branch doesn't exist, it is created, unless
+	// configured otherwise via switchOpts.
+	SwitchBranch(ctx context.Context, branch string, switchOpts ...SwitchBranchOption) error
 	// Commit commits any changes made to the repository. commitOpts is an
 	// optional argument which can be provided to configure the commit.
 	Commit(info git.Commit, commitOpts ...CommitOption) (string, error)
@@ -20,6 +20,8 @@ import (
 	"io"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
+
+	"github.com/fluxcd/pkg/git"
 )
 
 const (
@@ -51,6 +53,16 @@ type CloneConfig struct {
 	// ShallowClone defines if the repository should be shallow cloned,
 	// not supported by all implementations
 	ShallowClone bool
+
+	// ExpectedContentDigest is the expected digest of the checked out
+	// worktree, in the format of "sha256:<hex>". If provided, the clone
+	// operation computes a deterministic digest over the content of the
+	// worktree (excluding any .git entries) and fails if it does not match.
+	// This allows pinning the materialized content in addition to the
+	// commit, not supported by all implementations. For the gogit
+	// implementation, the digest of a worktree can be computed using
+	// gogit.ContentDigest, which also documents its format.
+	ExpectedContentDigest string
 }
 
 // PushConfig provides configuration options for a Git push.
@@ -63,12 +75,54 @@ type PushConfig struct {
 	// Force, if set to true, will result in a force push.
 	Force bool
 
+	// ForceWithLease, if set to true, will result in a force push which
+	// is only performed if the remote references still point to the
+	// commits of their remote-tracking references in the repository. It
+	// takes precedence over Force, and is only supported when pushing
+	// to origin.
+	ForceWithLease bool
+
+	// RemoteURL is the URL of the remote to push to. If empty, the push
+	// is made to origin.
+	RemoteURL string
+
+	// AuthOptions are the authentication options used for pushing to
+	// RemoteURL. If nil, the authentication options of the client are
+	// used.
+	AuthOptions *git.AuthOptions
+
+	// Mirror, if set to true, will result in all the local branches and
+	// tags being force pushed to the remote, and the remote branches and
+	// tags which do not exist locally being removed. It takes precedence
+	// over Refspecs, Force and ForceWithLease, and requires RemoteURL to
+	// be set, as a clone usually does not contain all the branches and
+	// tags of origin.
+	Mirror bool
+
 	// Options is a map specifying the push options that are sent
 	// to the Git server when performing a push option. For details, see:
 	// https://git-scm.com/docs/git-push#Documentation/git-push.txt---push-optionltoptiongt
 	Options map[string]string
 }
 
+// FetchConfig provides configuration options for a Git fetch.
+type FetchConfig struct {
+	// Refspecs is a list of refspecs to use for the fetch operation.
+	// If empty, the fetch refspecs configured for the origin remote
+	// are used.
+	// For details about Git Refspecs, please see:
+	// https://git-scm.com/book/en/v2/Git-Internals-The-Refspec
+	Refspecs []string
+
+	// Tags, if set to true, will result in all the tags of the remote
+	// being fetched. Otherwise, no tags are fetched.
+	Tags bool
+
+	// Prune, if set to true, will remove the local references matching
+	// the refspecs which no longer exist on the remote.
+	Prune bool
+}
+
 // CheckoutStrategy provides options to checkout a repository to a target.
 type CheckoutStrategy struct {
 	// Branch to checkout. If supported by the client, it can be combined
@@ -89,6 +143,9 @@ type CheckoutStrategy struct {
 
 	// Commit SHA1 to checkout, takes precedence over all the other options.
 	// If supported by the client, it can be combined with Branch.
+	// It can also be combined with RefName to provide the reference the
+	// commit was selected from, which is then reported as the Reference
+	// of the returned commit without the client searching the remote refs.
 	Commit string
 }
 
@@ -99,6 +156,15 @@ type CommitOptions struct {
 	// Files contains file names mapped to the file's content.
 	// Its used to write files which are then included in the commit.
 	Files map[string]io.Reader
+	// DeletedFiles contains the names of files to be deleted, with the
+	// deletion included in the commit.
+	DeletedFiles []string
+	// RenamedFiles contains file names mapped to their new file names.
+	// Its used to rename files, with the rename included in the commit.
+	// As the renames are not ordered, a file can not be renamed to the
+	// old name of another renamed file, nor can several files be renamed
+	// to the same name.
+	RenamedFiles map[string]string
 }
 
 // CommitOption defines an option for a commit operation.
@@ -121,3 +187,92 @@ func WithFiles(files map[string]io.Reader) CommitOption {
 		co.Files = files
 	}
 }
+
+// WithDeletedFiles instructs the Git client to delete the provided files
+// and include the deletions in the commit.
+func WithDeletedFiles(files []string) CommitOption {
+	return func(co *CommitOptions) {
+		co.DeletedFiles = files
+	}
+}
+
+// WithRenamedFiles instructs the Git client to rename the provided files
+// and include the renames in the commit.
+// files contains the current file names as its key and the new file name
+// as the value. Renames are applied before any files provided using
+// WithFiles are written, and before any deletions. Chained or swapped
+// renames, and renames of several files to the same name, are rejected.
+func WithRenamedFiles(files map[string]string) CommitOption {
+	return func(co *CommitOptions) {
+		co.RenamedFiles = files
+	}
+}
+
+// MergeStrategy defines how conflicting changes are resolved while
+// merging two commits. Conflicts are detected per file: a file changed on
+// both sides in different ways is a conflict, even if the changes do not
+// overlap.
+type MergeStrategy string
+
+const (
+	// MergeStrategyFail fails the merge if both sides changed the same
+	// file in different ways.
+	MergeStrategyFail MergeStrategy = "fail"
+	// MergeStrategyOurs resolves conflicts using the version of the
+	// current branch. The whole file is taken, dropping any changes made
+	// to it by the reference being merged.
+	MergeStrategyOurs MergeStrategy = "ours"
+	// MergeStrategyTheirs resolves conflicts using the version of the
+	// reference being merged. The whole file is taken, dropping any
+	// changes made to it on the current branch.
+	MergeStrategyTheirs MergeStrategy = "theirs"
+)
+
+// MergeOptions provides options to configure a Git merge operation.
+type MergeOptions struct {
+	// Strategy defines how conflicts are resolved. Defaults to
+	// MergeStrategyFail.
+	Strategy MergeStrategy
+}
+
+// MergeOption defines an option for a merge operation.
+type MergeOption func(*MergeOptions)
+
+// WithMergeStrategy sets the strategy used to resolve conflicts during
+// a merge.
+func WithMergeStrategy(strategy MergeStrategy) MergeOption {
+	return func(mo *MergeOptions) {
+		mo.Strategy = strategy
+	}
+}
+
+// SwitchBranchMode defines how a branch switch handles a branch that
+// does not exist.
+type SwitchBranchMode string
+
+const (
+	// SwitchBranchCreateIfMissing creates the branch from the current HEAD
+	// if it does not exist locally or remotely.
+	SwitchBranchCreateIfMissing SwitchBranchMode = "create-if-missing"
+	// SwitchBranchStrict only switches to a branch which exists locally or
+	// remotely, and fails otherwise.
+	SwitchBranchStrict SwitchBranchMode = "strict"
+)
+
+// SwitchBranchOptions provides options to configure a branch switch.
+type SwitchBranchOptions struct {
+	// Mode defines how a missing branch is handled. Defaults to
+	// SwitchBranchCreateIfMissing.
+	Mode SwitchBranchMode
+}
+
+// SwitchBranchOption defines an option for a branch switch.
+type SwitchBranchOption func(*SwitchBranchOptions)
+
+// WithSwitchBranchMode sets the mode used to handle a missing branch
+// during a branch switch.
+func WithSwitchBranchMode(mode SwitchBranchMode) SwitchBranchOption {
+	return func(so *SwitchBranchOptions) {
+		so.Mode = mode
+	}
+}
@@ -21,13 +21,17 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecrpublic"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -36,6 +40,14 @@ import (
 
 var registryPartRe = regexp.MustCompile(`([0-9+]*).dkr.ecr(?:-fips)?\.([^/.]*)\.(amazonaws\.com[.cn]*)`)
 
+const (
+	// PublicRegistryHost is the host of the ECR Public registry.
+	PublicRegistryHost = "public.ecr.aws"
+	// PublicRegistryRegion is the region in which the ECR Public
+	// authorization tokens are issued.
+	PublicRegistryRegion = "us-east-1"
+)
+
 // ParseRegistry returns the AWS account ID and region and `true` if
 // the image registry/repository is hosted in AWS's Elastic Container Registry,
 // otherwise empty strings and `false`.
@@ -47,11 +59,27 @@ func ParseRegistry(registry string) (accountId, awsEcrRegion string, ok bool) {
 	return registryParts[0][1], registryParts[0][2], true
 }
 
+// IsPublicRegistry returns true if the image registry/repository is hosted
+// in AWS's ECR Public registry, i.e. "public.ecr.aws".
+func IsPublicRegistry(registry string) bool {
+	host := registry
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexRune(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	return host == PublicRegistryHost
+}
+
 // Client is a AWS ECR client which can log into the registry and return
 // authorization information.
 type Client struct {
 	config *aws.Config
-	mu     sync.Mutex
+	// regionConfigs holds the default configs loaded per region, which
+	// are used if no config is set using WithConfig.
+	regionConfigs map[string]*aws.Config
+	mu            sync.Mutex
 }
 
 // NewClient creates a new empty ECR client.
@@ -72,50 +100,101 @@ func (c *Client) WithConfig(cfg *aws.Config) {
 	}
 }
 
+// loadConfig returns a copy of the client config. If the client config is
+// uninitialized, a copy of the default config for the given region is
+// returned, which is loaded once per region. If a proxy URL is given, the
+// returned config uses it for its requests.
+func (c *Client) loadConfig(ctx context.Context, awsEcrRegion string, proxyURL *url.URL) (aws.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	base := c.config
+	if base == nil {
+		base = c.regionConfigs[awsEcrRegion]
+	}
+	if base == nil {
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(awsEcrRegion))
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("failed to load default configuration: %w", err)
+		}
+		if c.regionConfigs == nil {
+			c.regionConfigs = map[string]*aws.Config{}
+		}
+		c.regionConfigs[awsEcrRegion] = &cfg
+		base = &cfg
+	}
+
+	cfg := base.Copy()
+	if proxyURL != nil {
+		cfg.HTTPClient = awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+			t.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+	return cfg, nil
+}
+
 // getLoginAuth obtains authentication for ECR given the
 // region (taken from the image). This assumes that the pod has
 // IAM permissions to get an authentication token, which will usually
 // be the case if it's running in EKS, and may need additional setup
 // otherwise (visit https://aws.github.io/aws-sdk-go-v2/docs/configuring-sdk/
 // as a starting point).
-func (c *Client) getLoginAuth(ctx context.Context, awsEcrRegion string) (authn.AuthConfig, error) {
+func (c *Client) getLoginAuth(ctx context.Context, awsEcrRegion string, proxyURL *url.URL) (authn.AuthConfig, error) {
 	// No caching of tokens is attempted; the quota for getting an
 	// auth token is high enough that getting a token every time you
 	// scan an image is viable for O(500) images per region. See
 	// https://docs.aws.amazon.com/general/latest/gr/ecr.html.
-	var authConfig authn.AuthConfig
-	var cfg aws.Config
-
-	c.mu.Lock()
-	if c.config != nil {
-		cfg = c.config.Copy()
-	} else {
-		var err error
-		cfg, err = config.LoadDefaultConfig(ctx, config.WithRegion(awsEcrRegion))
-		if err != nil {
-			c.mu.Unlock()
-			return authConfig, fmt.Errorf("failed to load default configuration: %w", err)
-		}
-		c.config = &cfg
+	cfg, err := c.loadConfig(ctx, awsEcrRegion, proxyURL)
+	if err != nil {
+		return authn.AuthConfig{}, err
 	}
-	c.mu.Unlock()
 
 	ecrService := ecr.NewFromConfig(cfg)
 	// NOTE: ecr.GetAuthorizationTokenInput has deprecated RegistryIds. Hence,
 	// pass nil input.
 	ecrToken, err := ecrService.GetAuthorizationToken(ctx, nil)
 	if err != nil {
-		return authConfig, err
+		return authn.AuthConfig{}, err
 	}
 
 	// Validate the authorization data.
 	if len(ecrToken.AuthorizationData) == 0 {
-		return authConfig, errors.New("no authorization data")
+		return authn.AuthConfig{}, errors.New("no authorization data")
+	}
+	return authConfigFromToken(ecrToken.AuthorizationData[0].AuthorizationToken)
+}
+
+// getPublicLoginAuth obtains authentication for ECR Public. Unlike ECR,
+// the ECR Public authorization tokens are always issued by the ecr-public
+// service in the PublicRegistryRegion, regardless of the region the client
+// is configured with.
+func (c *Client) getPublicLoginAuth(ctx context.Context, proxyURL *url.URL) (authn.AuthConfig, error) {
+	cfg, err := c.loadConfig(ctx, PublicRegistryRegion, proxyURL)
+	if err != nil {
+		return authn.AuthConfig{}, err
+	}
+	cfg.Region = PublicRegistryRegion
+
+	ecrPublicService := ecrpublic.NewFromConfig(cfg)
+	ecrToken, err := ecrPublicService.GetAuthorizationToken(ctx, &ecrpublic.GetAuthorizationTokenInput{})
+	if err != nil {
+		return authn.AuthConfig{}, err
+	}
+
+	// Validate the authorization data.
+	if ecrToken.AuthorizationData == nil {
+		return authn.AuthConfig{}, errors.New("no authorization data")
 	}
-	if ecrToken.AuthorizationData[0].AuthorizationToken == nil {
+	return authConfigFromToken(ecrToken.AuthorizationData.AuthorizationToken)
+}
+
+// authConfigFromToken decodes the base64 encoded "<username>:<password>"
+// authorization token returned by ECR and ECR Public.
+func authConfigFromToken(authToken *string) (authn.AuthConfig, error) {
+	var authConfig authn.AuthConfig
+	if authToken == nil {
 		return authConfig, fmt.Errorf("no authorization token")
 	}
-	token, err := base64.StdEncoding.DecodeString(*ecrToken.AuthorizationData[0].AuthorizationToken)
+	token, err := base64.StdEncoding.DecodeString(*authToken)
 	if err != nil {
 		return authConfig, err
 	}
@@ -132,34 +211,35 @@ func (c *Client) getLoginAuth(ctx context.Context, awsEcrRegion string) (authn.A
 	return authConfig, nil
 }
 
-// Login attempts to get the authentication material for ECR.
-func (c *Client) Login(ctx context.Context, autoLogin bool, image string) (authn.Authenticator, error) {
+// Login attempts to get the authentication material for ECR or ECR Public.
+func (c *Client) Login(ctx context.Context, autoLogin bool, image string, opts ...oci.LoginOption) (authn.Authenticator, error) {
 	if autoLogin {
 		log.FromContext(ctx).Info("logging in to AWS ECR for " + image)
-		_, awsEcrRegion, ok := ParseRegistry(image)
-		if !ok {
-			return nil, errors.New("failed to parse AWS ECR image, invalid ECR image")
-		}
-
-		authConfig, err := c.getLoginAuth(ctx, awsEcrRegion)
-		if err != nil {
-			return nil, err
-		}
-
-		auth := authn.FromConfig(authConfig)
-		return auth, nil
+		return c.login(ctx, image, oci.NewLoginOptions(opts...))
 	}
 	return nil, fmt.Errorf("ECR authentication failed: %w", oci.ErrUnconfiguredProvider)
 }
 
-// OIDCLogin attempts to get the authentication material for ECR.
-func (c *Client) OIDCLogin(ctx context.Context, registryURL string) (authn.Authenticator, error) {
-	_, awsEcrRegion, ok := ParseRegistry(registryURL)
-	if !ok {
-		return nil, errors.New("failed to parse AWS ECR image, invalid ECR image")
-	}
+// OIDCLogin attempts to get the authentication material for ECR or ECR
+// Public.
+func (c *Client) OIDCLogin(ctx context.Context, registryURL string, opts ...oci.LoginOption) (authn.Authenticator, error) {
+	return c.login(ctx, registryURL, oci.NewLoginOptions(opts...))
+}
 
-	authConfig, err := c.getLoginAuth(ctx, awsEcrRegion)
+func (c *Client) login(ctx context.Context, image string, opts oci.LoginOptions) (authn.Authenticator, error) {
+	var (
+		authConfig authn.AuthConfig
+		err        error
+	)
+	if IsPublicRegistry(image) {
+		authConfig, err = c.getPublicLoginAuth(ctx, opts.ProxyURL)
+	} else {
+		_, awsEcrRegion, ok := ParseRegistry(image)
+		if !ok {
+			return nil, errors.New("failed to parse AWS ECR image, invalid ECR image")
+		}
+		authConfig, err = c.getLoginAuth(ctx, awsEcrRegion, opts.ProxyURL)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +98,125 @@ func TestParseRegistry(t *testing.T) {
 	}
 }
 
+func TestIsPublicRegistry(t *testing.T) {
+	tests := []struct {
+		registry string
+		want     bool
+	}{
+		{registry: "public.ecr.aws", want: true},
+		{registry: "public.ecr.aws/foo/bar:v1", want: true},
+		{registry: "https://public.ecr.aws/v2/foo/bar", want: true},
+		{registry: "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1", want: false},
+		{registry: "public.ecr.aws.example.com/foo", want: false},
+		{registry: "gcr.io/public.ecr.aws", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.registry, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(IsPublicRegistry(tt.registry)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	// Logging in to ECR Public first must not pin the region of later
+	// ECR logins.
+	ec := NewClient()
+	cfg, err := ec.loadConfig(context.TODO(), PublicRegistryRegion, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Region).To(Equal(PublicRegistryRegion))
+
+	cfg, err = ec.loadConfig(context.TODO(), "eu-west-1", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Region).To(Equal("eu-west-1"))
+
+	cfg, err = ec.loadConfig(context.TODO(), PublicRegistryRegion, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Region).To(Equal(PublicRegistryRegion))
+
+	// A config set using WithConfig is used for all regions.
+	ec = NewClient()
+	ec.WithConfig(&aws.Config{Region: "ap-south-1"})
+	cfg, err = ec.loadConfig(context.TODO(), "eu-west-1", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Region).To(Equal("ap-south-1"))
+}
+
+func TestGetPublicLoginAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseBody   []byte
+		statusCode     int
+		wantErr        bool
+		wantAuthConfig authn.AuthConfig
+	}{
+		{
+			// NOTE: The authorizationToken is base64 encoded.
+			name: "success",
+			responseBody: []byte(`{
+	"authorizationData": {
+		"authorizationToken": "c29tZS1rZXk6c29tZS1zZWNyZXQ="
+	}
+}`),
+			statusCode: http.StatusOK,
+			wantAuthConfig: authn.AuthConfig{
+				Username: "some-key",
+				Password: "some-secret",
+			},
+		},
+		{
+			name:       "fail",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+		{
+			name:         "invalid response",
+			responseBody: []byte(`{}`),
+			statusCode:   http.StatusOK,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.responseBody))
+			}
+			srv := httptest.NewServer(http.HandlerFunc(handler))
+			t.Cleanup(func() {
+				srv.Close()
+			})
+
+			// Configure test client in a region different from the one
+			// ECR Public tokens are issued in.
+			var gotService, gotRegion string
+			ec := NewClient()
+			cfg := aws.NewConfig()
+			cfg.Region = "eu-west-1"
+			cfg.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				gotService, gotRegion = service, region
+				return aws.Endpoint{URL: srv.URL}, nil
+			})
+			cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
+			ec.WithConfig(cfg)
+
+			a, err := ec.getPublicLoginAuth(context.TODO(), nil)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			if !tt.wantErr {
+				g.Expect(a).To(Equal(tt.wantAuthConfig))
+			}
+			g.Expect(gotService).To(Equal("ECR PUBLIC"))
+			g.Expect(gotRegion).To(Equal(PublicRegistryRegion))
+		})
+	}
+}
+
 func TestGetLoginAuth(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -183,7 +302,7 @@ func TestGetLoginAuth(t *testing.T) {
 			cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
 			ec.WithConfig(cfg)
 
-			a, err := ec.getLoginAuth(context.TODO(), "us-east-1")
+			a, err := ec.getLoginAuth(context.TODO(), "us-east-1", nil)
 			g.Expect(err != nil).To(Equal(tt.wantErr))
 			if tt.statusCode == http.StatusOK {
 				g.Expect(a).To(Equal(tt.wantAuthConfig))
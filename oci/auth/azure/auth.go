@@ -19,7 +19,10 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	_ "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
@@ -38,6 +41,11 @@ import (
 type Client struct {
 	credential azcore.TokenCredential
 	scheme     string
+
+	// defaultCredentials holds the default token credentials used if no
+	// token credential is set, which are loaded once per proxy URL.
+	defaultCredentials map[string]azcore.TokenCredential
+	mu                 sync.Mutex
 }
 
 // NewClient creates a new ACR client with default configurations.
@@ -57,26 +65,40 @@ func (c *Client) WithScheme(scheme string) *Client {
 	return c
 }
 
+// httpClient returns the HTTP client used for the requests of a login,
+// which uses the given proxy URL if it is not nil.
+func httpClient(proxyURL *url.URL) *http.Client {
+	client := &http.Client{}
+	if proxyURL != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client.Transport = transport
+	}
+	return client
+}
+
 // getLoginAuth returns authentication for ACR. The details needed for authentication
 // are gotten from environment variable so there is no need to mount a host path.
 // The endpoint is the registry server and will be queried for OAuth authorization token.
-func (c *Client) getLoginAuth(ctx context.Context, registryURL string) (authn.AuthConfig, error) {
+// The requests for obtaining and exchanging tokens use the given proxy URL,
+// except for the requests of a token credential set using WithTokenCredential.
+func (c *Client) getLoginAuth(ctx context.Context, registryURL string, proxyURL *url.URL) (authn.AuthConfig, error) {
 	var authConfig authn.AuthConfig
 
 	// Use default credentials if no token credential is provided.
 	// NOTE: NewDefaultAzureCredential() performs a lot of environment lookup
 	// for creating default token credential. Load it only when it's needed.
-	if c.credential == nil {
-		cred, err := azidentity.NewDefaultAzureCredential(nil)
-		if err != nil {
+	credential := c.credential
+	if credential == nil {
+		var err error
+		if credential, err = c.loadDefaultCredential(proxyURL); err != nil {
 			return authConfig, err
 		}
-		c.credential = cred
 	}
 
 	configurationEnvironment := getCloudConfiguration(registryURL)
 	// Obtain access token using the token credential.
-	armToken, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{
+	armToken, err := credential.GetToken(ctx, policy.TokenRequestOptions{
 		Scopes: []string{configurationEnvironment.Services[cloud.ResourceManager].Endpoint + "/" + ".default"},
 	})
 	if err != nil {
@@ -84,7 +106,7 @@ func (c *Client) getLoginAuth(ctx context.Context, registryURL string) (authn.Au
 	}
 
 	// Obtain ACR access token using exchanger.
-	ex := newExchanger(registryURL)
+	ex := newExchanger(registryURL).withHTTPClient(httpClient(proxyURL))
 	accessToken, err := ex.ExchangeACRAccessToken(string(armToken.Token))
 	if err != nil {
 		return authConfig, fmt.Errorf("error exchanging token: %w", err)
@@ -98,9 +120,39 @@ func (c *Client) getLoginAuth(ctx context.Context, registryURL string) (authn.Au
 	}, nil
 }
 
+// loadDefaultCredential returns the default token credential using the
+// given proxy URL, which is loaded once per proxy URL so that its tokens
+// are reused across logins.
+func (c *Client) loadDefaultCredential(proxyURL *url.URL) (azcore.TokenCredential, error) {
+	var key string
+	var opts *azidentity.DefaultAzureCredentialOptions
+	if proxyURL != nil {
+		key = proxyURL.String()
+		opts = &azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: azcore.ClientOptions{Transport: httpClient(proxyURL)},
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cred, ok := c.defaultCredentials[key]; ok {
+		return cred, nil
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.defaultCredentials == nil {
+		c.defaultCredentials = map[string]azcore.TokenCredential{}
+	}
+	c.defaultCredentials[key] = cred
+	return cred, nil
+}
+
 // getCloudConfiguration returns the cloud configuration based on the registry URL.
 // List from https://github.com/Azure/azure-sdk-for-go/blob/main/sdk/containers/azcontainerregistry/cloud_config.go#L16
 func getCloudConfiguration(url string) cloud.Configuration {
+	url = normalizeHost(url)
 	switch {
 	case strings.HasSuffix(url, ".azurecr.cn"):
 		return cloud.AzureChina
@@ -113,7 +165,9 @@ func getCloudConfiguration(url string) cloud.Configuration {
 
 // ValidHost returns if a given host is a Azure container registry.
 // List from https://github.com/kubernetes/kubernetes/blob/v1.23.1/pkg/credentialprovider/azure/azure_credentials.go#L55
+// The host is matched case-insensitively, and a trailing dot is ignored.
 func ValidHost(host string) bool {
+	host = normalizeHost(host)
 	for _, v := range []string{".azurecr.io", ".azurecr.cn", ".azurecr.de", ".azurecr.us"} {
 		if strings.HasSuffix(host, v) {
 			return true
@@ -122,15 +176,22 @@ func ValidHost(host string) bool {
 	return false
 }
 
+// normalizeHost returns the given host in lower case and without the
+// trailing dot of a fully qualified domain name, as host names are
+// case-insensitive.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
 // Login attempts to get the authentication material for ACR. The caller can
 // ensure that the passed image is a valid ACR image using ValidHost().
-func (c *Client) Login(ctx context.Context, autoLogin bool, image string, ref name.Reference) (authn.Authenticator, error) {
+func (c *Client) Login(ctx context.Context, autoLogin bool, image string, ref name.Reference, opts ...oci.LoginOption) (authn.Authenticator, error) {
 	if autoLogin {
 		log.FromContext(ctx).Info("logging in to Azure ACR for " + image)
 		// get registry host from image
 		strArr := strings.SplitN(image, "/", 2)
-		endpoint := fmt.Sprintf("%s://%s", c.scheme, strArr[0])
-		authConfig, err := c.getLoginAuth(ctx, endpoint)
+		endpoint := fmt.Sprintf("%s://%s", c.scheme, normalizeHost(strArr[0]))
+		authConfig, err := c.getLoginAuth(ctx, endpoint, oci.NewLoginOptions(opts...).ProxyURL)
 		if err != nil {
 			log.FromContext(ctx).Info("error logging into ACR " + err.Error())
 			return nil, err
@@ -146,8 +207,12 @@ func (c *Client) Login(ctx context.Context, autoLogin bool, image string, ref na
 //
 // If you want to construct an Authenticator based on an image reference,
 // you may want to use Login instead.
-func (c *Client) OIDCLogin(ctx context.Context, registryUrl string) (authn.Authenticator, error) {
-	authConfig, err := c.getLoginAuth(ctx, registryUrl)
+func (c *Client) OIDCLogin(ctx context.Context, registryUrl string, opts ...oci.LoginOption) (authn.Authenticator, error) {
+	if u, err := url.Parse(registryUrl); err == nil && u.Host != "" {
+		u.Host = normalizeHost(u.Host)
+		registryUrl = u.String()
+	}
+	authConfig, err := c.getLoginAuth(ctx, registryUrl, oci.NewLoginOptions(opts...).ProxyURL)
 	if err != nil {
 		log.FromContext(ctx).Info("error logging into ACR " + err.Error())
 		return nil, err
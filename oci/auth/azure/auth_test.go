@@ -84,7 +84,7 @@ func TestGetAzureLoginAuth(t *testing.T) {
 				WithTokenCredential(tt.tokenCredential).
 				WithScheme("http")
 
-			auth, err := c.getLoginAuth(context.TODO(), srv.URL)
+			auth, err := c.getLoginAuth(context.TODO(), srv.URL, nil)
 			g.Expect(err != nil).To(Equal(tt.wantErr))
 			if tt.statusCode == http.StatusOK {
 				g.Expect(auth).To(Equal(tt.wantAuthConfig))
@@ -102,6 +102,9 @@ func TestValidHost(t *testing.T) {
 		{"foo.azurecr.cn", true},
 		{"foo.azurecr.de", true},
 		{"foo.azurecr.us", true},
+		{"Foo.AzureCR.io", true},
+		{"FOO.AZURECR.CN", true},
+		{"foo.azurecr.io.", true},
 		{"gcr.io", false},
 		{"docker.io", false},
 	}
@@ -123,6 +126,8 @@ func TestGetCloudConfiguration(t *testing.T) {
 		{"foo.azurecr.cn", cloud.AzureChina},
 		{"foo.azurecr.de", cloud.AzurePublic},
 		{"foo.azurecr.us", cloud.AzureGovernment},
+		{"Foo.AzureCR.CN", cloud.AzureChina},
+		{"foo.azurecr.us.", cloud.AzureGovernment},
 	}
 
 	for _, tt := range tests {
@@ -197,3 +202,25 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadDefaultCredential(t *testing.T) {
+	g := NewWithT(t)
+
+	proxyURL, err := url.Parse("http://proxy.example.com")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	c := NewClient()
+	cred, err := c.loadDefaultCredential(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	proxyCred, err := c.loadDefaultCredential(proxyURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(proxyCred).ToNot(BeIdenticalTo(cred))
+
+	// The credentials are reused per proxy URL.
+	got, err := c.loadDefaultCredential(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(BeIdenticalTo(cred))
+	got, err = c.loadDefaultCredential(proxyURL)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(BeIdenticalTo(proxyCred))
+}
@@ -51,6 +51,8 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+
+	"github.com/fluxcd/pkg/oci"
 )
 
 type tokenResponse struct {
@@ -66,17 +68,25 @@ type acrError struct {
 }
 
 type exchanger struct {
-	endpoint string
+	endpoint   string
+	httpClient *http.Client
 }
 
 // newExchanger returns an Azure Exchanger for Azure Container Registry with
 // a given endpoint, for example https://azurecr.io.
 func newExchanger(endpoint string) *exchanger {
 	return &exchanger{
-		endpoint: endpoint,
+		endpoint:   endpoint,
+		httpClient: http.DefaultClient,
 	}
 }
 
+// withHTTPClient sets the HTTP client used for the exchange requests.
+func (e *exchanger) withHTTPClient(c *http.Client) *exchanger {
+	e.httpClient = c
+	return e
+}
+
 // ExchangeACRAccessToken exchanges an access token for a refresh token with the
 // exchange service.
 func (e *exchanger) ExchangeACRAccessToken(armToken string) (string, error) {
@@ -92,7 +102,7 @@ func (e *exchanger) ExchangeACRAccessToken(armToken string) (string, error) {
 	parameters.Add("service", exchangeURL.Hostname())
 	parameters.Add("access_token", armToken)
 
-	resp, err := http.PostForm(exchangeURL.String(), parameters)
+	resp, err := e.httpClient.PostForm(exchangeURL.String(), parameters)
 	if err != nil {
 		return "", fmt.Errorf("failed to send token exchange request: %w", err)
 	}
@@ -106,13 +116,19 @@ func (e *exchanger) ExchangeACRAccessToken(armToken string) (string, error) {
 		// Parse the error response.
 		var errors []acrError
 		if err = json.Unmarshal(b, &errors); err == nil {
-			return "", fmt.Errorf("unexpected status code %d from exchange request: %s",
-				resp.StatusCode, errors)
+			return "", &oci.StatusError{
+				StatusCode: resp.StatusCode,
+				Err: fmt.Errorf("unexpected status code %d from exchange request: %s",
+					resp.StatusCode, errors),
+			}
 		}
 
 		// Error response could not be parsed, return a generic error.
-		return "", fmt.Errorf("unexpected status code %d from exchange request, response body: %s",
-			resp.StatusCode, string(b))
+		return "", &oci.StatusError{
+			StatusCode: resp.StatusCode,
+			Err: fmt.Errorf("unexpected status code %d from exchange request, response body: %s",
+				resp.StatusCode, string(b)),
+		}
 	}
 
 	var tokenResp tokenResponse
@@ -85,7 +85,10 @@ func (c *Client) getLoginAuth(ctx context.Context) (authn.AuthConfig, error) {
 	defer io.Copy(io.Discard, response.Body)
 
 	if response.StatusCode != http.StatusOK {
-		return authConfig, fmt.Errorf("unexpected status from metadata service: %s", response.Status)
+		return authConfig, &oci.StatusError{
+			StatusCode: response.StatusCode,
+			Err:        fmt.Errorf("unexpected status from metadata service: %s", response.Status),
+		}
 	}
 
 	var accessToken gceToken
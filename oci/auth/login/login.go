@@ -18,12 +18,16 @@ package login
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/fluxcd/pkg/oci"
@@ -35,18 +39,10 @@ import (
 // ImageRegistryProvider analyzes the provided registry and returns the identified
 // container image registry provider.
 func ImageRegistryProvider(url string, ref name.Reference) oci.Provider {
-	// If the url is a repository root address, use it to analyze. Else, derive
-	// the registry from the name reference.
-	// NOTE: This is because name.Reference of a repository root assumes that
-	// the reference is an image name and defaults to using index.docker.io as
-	// the registry host.
-	addr := strings.TrimSuffix(url, "/")
-	if strings.ContainsRune(addr, '/') {
-		addr = ref.Context().RegistryStr()
-	}
+	addr := registryAddress(url, ref)
 
 	_, _, ok := aws.ParseRegistry(addr)
-	if ok {
+	if ok || aws.IsPublicRegistry(addr) {
 		return oci.ProviderAWS
 	}
 	if gcp.ValidHost(addr) {
@@ -58,6 +54,20 @@ func ImageRegistryProvider(url string, ref name.Reference) oci.Provider {
 	return oci.ProviderGeneric
 }
 
+// registryAddress returns the registry host of the provided image address.
+func registryAddress(url string, ref name.Reference) string {
+	// If the url is a repository root address, use it to analyze. Else, derive
+	// the registry from the name reference.
+	// NOTE: This is because name.Reference of a repository root assumes that
+	// the reference is an image name and defaults to using index.docker.io as
+	// the registry host.
+	addr := strings.TrimSuffix(url, "/")
+	if strings.ContainsRune(addr, '/') {
+		addr = ref.Context().RegistryStr()
+	}
+	return addr
+}
+
 // ProviderOptions contains options for registry provider login.
 type ProviderOptions struct {
 	// AwsAutoLogin enables automatic attempt to get credentials for images in
@@ -69,6 +79,43 @@ type ProviderOptions struct {
 	// AzureAutoLogin enables automatic attempt to get credentials for images in
 	// ACR.
 	AzureAutoLogin bool
+	// AllowAnonymous enables checking whether the image can be pulled
+	// without credentials before logging in with the registry provider.
+	// If it can, authn.Anonymous is returned and no provider login is
+	// attempted. It has no effect on OIDCLogin, as no image is known.
+	// The check uses the transport of the Manager, with ProxyURL set as
+	// its proxy.
+	AllowAnonymous bool
+	// ProxyURL is the URL of the proxy to use for the token exchanges of
+	// the AWS and Azure registry providers. It only applies to the login it
+	// is passed to. GCP tokens are obtained from the link-local metadata
+	// server, which is always reached without ProxyURL.
+	ProxyURL *url.URL
+	// Mirrors is an ordered list of registry hosts to fall back to when
+	// logging in to the primary registry fails. The registry host of the
+	// image or registry URL is replaced with each mirror host in turn.
+	// It is only used by LoginWithMirrors and OIDCLoginWithMirrors, which
+	// return the registry host the credentials are for.
+	Mirrors []string
+	// Retry configures the retries of the registry provider login when a
+	// token exchange fails with a retryable HTTP status code. By default,
+	// the login is not retried.
+	Retry RetryOptions
+}
+
+// RetryOptions configures the retries of a registry provider login.
+// Logins are retried with an exponential backoff when a token exchange
+// fails with a 408, 429 or 5xx HTTP status code. Authentication failures
+// are never retried.
+type RetryOptions struct {
+	// MaxRetries is the maximum number of times the login is retried.
+	MaxRetries int
+	// InitialBackoff is the time to wait before the first retry, which is
+	// doubled for every subsequent retry. Defaults to 1 second.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time to wait between retries. Defaults to
+	// 30 seconds.
+	MaxBackoff time.Duration
 }
 
 // Manager is a login manager for various registry providers.
@@ -76,15 +123,19 @@ type Manager struct {
 	ecr *aws.Client
 	gcr *gcp.Client
 	acr *azure.Client
+
+	// transport is used to check for anonymous access to images.
+	transport http.RoundTripper
 }
 
 // NewManager initializes a Manager with default registry clients
 // configurations.
 func NewManager() *Manager {
 	return &Manager{
-		ecr: aws.NewClient(),
-		gcr: gcp.NewClient(),
-		acr: azure.NewClient(),
+		ecr:       aws.NewClient(),
+		gcr:       gcp.NewClient(),
+		acr:       azure.NewClient(),
+		transport: remote.DefaultTransport,
 	}
 }
 
@@ -106,42 +157,178 @@ func (m *Manager) WithACRClient(c *azure.Client) *Manager {
 	return m
 }
 
+// WithTransport allows overriding the default transport used to check for
+// anonymous access to images, for example to configure TLS.
+func (m *Manager) WithTransport(tr http.RoundTripper) *Manager {
+	m.transport = tr
+	return m
+}
+
 // Login performs authentication against a registry and returns the Authenticator.
 // For generic registry provider, it is no-op.
+//
+// Login only authenticates against the registry of the image, opts.Mirrors
+// is ignored. Use LoginWithMirrors to fall back to mirrors, which returns
+// the registry host the Authenticator is for.
 func (m *Manager) Login(ctx context.Context, url string, ref name.Reference, opts ProviderOptions) (authn.Authenticator, error) {
-	switch ImageRegistryProvider(url, ref) {
-	case oci.ProviderAWS:
-		return m.ecr.Login(ctx, opts.AwsAutoLogin, url)
-	case oci.ProviderGCP:
-		return m.gcr.Login(ctx, opts.GcpAutoLogin, url, ref)
-	case oci.ProviderAzure:
-		return m.acr.Login(ctx, opts.AzureAutoLogin, url, ref)
+	return m.login(ctx, url, ref, opts)
+}
+
+// LoginWithMirrors performs authentication against the registry of the
+// image, and, if that fails, against each of the opts.Mirrors in order. It
+// returns the Authenticator of the first successful login together with the
+// registry host it was obtained for. If all attempts fail, the errors are
+// aggregated into the returned error.
+func (m *Manager) LoginWithMirrors(ctx context.Context, url string, ref name.Reference, opts ProviderOptions) (authn.Authenticator, string, error) {
+	host := registryAddress(url, ref)
+	auth, err := m.login(ctx, url, ref, opts)
+	if err == nil || len(opts.Mirrors) == 0 {
+		return auth, host, err
 	}
-	return nil, nil
+
+	errs := []error{fmt.Errorf("%s: %w", host, err)}
+	for _, mirror := range opts.Mirrors {
+		mirrorURL, mirrorRef, err := mirrorReference(url, ref, mirror)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+			continue
+		}
+		log.FromContext(ctx).Info("falling back to registry mirror " + mirror)
+		auth, err := m.login(ctx, mirrorURL, mirrorRef, opts)
+		if err == nil {
+			return auth, mirror, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+func (m *Manager) login(ctx context.Context, url string, ref name.Reference, opts ProviderOptions) (authn.Authenticator, error) {
+	provider := ImageRegistryProvider(url, ref)
+	if provider != oci.ProviderGeneric && opts.AllowAnonymous && m.anonymousAccess(ctx, url, ref, opts.ProxyURL) {
+		log.FromContext(ctx).Info("anonymous access allowed for " + url)
+		return authn.Anonymous, nil
+	}
+
+	return retry(ctx, opts.Retry, func() (authn.Authenticator, error) {
+		switch provider {
+		case oci.ProviderAWS:
+			return m.ecr.Login(ctx, opts.AwsAutoLogin, url, oci.WithProxyURL(opts.ProxyURL))
+		case oci.ProviderGCP:
+			return m.gcr.Login(ctx, opts.GcpAutoLogin, url, ref)
+		case oci.ProviderAzure:
+			return m.acr.Login(ctx, opts.AzureAutoLogin, url, ref, oci.WithProxyURL(opts.ProxyURL))
+		}
+		return nil, nil
+	})
+}
+
+// retry calls login until it succeeds, fails with an error which is not
+// retryable, or the retries are exhausted. It does not wait for a retry
+// past the deadline of the context.
+func retry(ctx context.Context, opts RetryOptions, login func() (authn.Authenticator, error)) (authn.Authenticator, error) {
+	backoff := opts.InitialBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	for i := 0; ; i++ {
+		auth, err := login()
+		if err == nil || i >= opts.MaxRetries || !retryable(err) {
+			return auth, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			return nil, err
+		}
+		log.FromContext(ctx).Info(fmt.Sprintf("retrying login in %s: %s", backoff, err))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// retryable returns true if the error has a retryable HTTP status code.
+func retryable(err error) bool {
+	var statusErr interface{ HTTPStatusCode() int }
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	code := statusErr.HTTPStatusCode()
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests ||
+		code >= http.StatusInternalServerError
 }
 
 // OIDCLogin attempts to get an Authenticator for the provided URL endpoint.
 //
 // If you want to construct an Authenticator based on an image reference,
 // you may want to use Login instead.
+//
+// OIDCLogin only authenticates against the provided registry, opts.Mirrors
+// is ignored. Use OIDCLoginWithMirrors to fall back to mirrors, which
+// returns the registry host the Authenticator is for.
 func (m *Manager) OIDCLogin(ctx context.Context, registryURL string, opts ProviderOptions) (authn.Authenticator, error) {
 	u, err := url.Parse(registryURL)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse registry url: %w", err)
 	}
+	return m.oidcLogin(ctx, u, opts)
+}
 
-	provider := ImageRegistryProvider(u.Host, nil)
+// OIDCLoginWithMirrors attempts to get an Authenticator for the provided URL
+// endpoint, and, if that fails, for each of the opts.Mirrors in order. It
+// returns the Authenticator of the first successful login together with the
+// registry host it was obtained for. If all attempts fail, the errors are
+// aggregated into the returned error.
+func (m *Manager) OIDCLoginWithMirrors(ctx context.Context, registryURL string, opts ProviderOptions) (authn.Authenticator, string, error) {
+	u, err := url.Parse(registryURL)
 	if err != nil {
-		return nil, fmt.Errorf("unable to set up provider: %w", err)
+		return nil, "", fmt.Errorf("unable to parse registry url: %w", err)
+	}
+
+	auth, err := m.oidcLogin(ctx, u, opts)
+	if err == nil || len(opts.Mirrors) == 0 {
+		return auth, u.Host, err
 	}
 
+	errs := []error{fmt.Errorf("%s: %w", u.Host, err)}
+	for _, mirror := range opts.Mirrors {
+		mirrorURL := *u
+		mirrorURL.Host = mirror
+		log.FromContext(ctx).Info("falling back to registry mirror " + mirror)
+		auth, err := m.oidcLogin(ctx, &mirrorURL, opts)
+		if err == nil {
+			return auth, mirror, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", mirror, err))
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+func (m *Manager) oidcLogin(ctx context.Context, u *url.URL, opts ProviderOptions) (authn.Authenticator, error) {
+	provider := ImageRegistryProvider(u.Host, nil)
+	return retry(ctx, opts.Retry, func() (authn.Authenticator, error) {
+		return m.providerOIDCLogin(ctx, provider, u, opts)
+	})
+}
+
+func (m *Manager) providerOIDCLogin(ctx context.Context, provider oci.Provider, u *url.URL, opts ProviderOptions) (authn.Authenticator, error) {
 	switch provider {
 	case oci.ProviderAWS:
 		if !opts.AwsAutoLogin {
 			return nil, fmt.Errorf("ECR authentication failed: %w", oci.ErrUnconfiguredProvider)
 		}
 		log.FromContext(ctx).Info("logging in to AWS ECR for " + u.Host)
-		return m.ecr.OIDCLogin(ctx, u.Host)
+		return m.ecr.OIDCLogin(ctx, u.Host, oci.WithProxyURL(opts.ProxyURL))
 	case oci.ProviderGCP:
 		if !opts.GcpAutoLogin {
 			return nil, fmt.Errorf("GCR authentication failed: %w", oci.ErrUnconfiguredProvider)
@@ -153,7 +340,72 @@ func (m *Manager) OIDCLogin(ctx context.Context, registryURL string, opts Provid
 			return nil, fmt.Errorf("ACR authentication failed: %w", oci.ErrUnconfiguredProvider)
 		}
 		log.FromContext(ctx).Info("logging in to Azure ACR for " + u.Host)
-		return m.acr.OIDCLogin(ctx, fmt.Sprintf("%s://%s", u.Scheme, u.Host))
+		return m.acr.OIDCLogin(ctx, fmt.Sprintf("%s://%s", u.Scheme, u.Host), oci.WithProxyURL(opts.ProxyURL))
 	}
 	return nil, nil
 }
+
+// anonymousAccess returns true if the image manifest can be fetched
+// without credentials. Repository root addresses can not be checked, and
+// always return false.
+func (m *Manager) anonymousAccess(ctx context.Context, url string, ref name.Reference, proxyURL *url.URL) bool {
+	if !strings.ContainsRune(strings.TrimSuffix(url, "/"), '/') {
+		return false
+	}
+	_, err := remote.Head(ref,
+		remote.WithContext(ctx),
+		remote.WithAuth(authn.Anonymous),
+		remote.WithTransport(m.probeTransport(proxyURL)),
+	)
+	return err == nil
+}
+
+// probeTransport returns the transport used to check for anonymous access.
+// If a proxy URL is given, it is set as the proxy of a copy of the
+// transport, which must then be an *http.Transport.
+func (m *Manager) probeTransport(proxyURL *url.URL) http.RoundTripper {
+	tr := m.transport
+	if tr == nil {
+		tr = remote.DefaultTransport
+	}
+	if proxyURL == nil {
+		return tr
+	}
+	if t, ok := tr.(*http.Transport); ok {
+		t = t.Clone()
+		t.Proxy = http.ProxyURL(proxyURL)
+		return t
+	}
+	return tr
+}
+
+// mirrorReference returns the given image address and reference with their
+// registry host replaced by the mirror host. The reference may be nil for a
+// repository root address, in which case the returned reference is nil too.
+func mirrorReference(url string, ref name.Reference, mirror string) (string, name.Reference, error) {
+	if ref == nil {
+		// Without a reference, the registry host can only be derived from
+		// a repository root address.
+		if strings.ContainsRune(strings.TrimSuffix(url, "/"), '/') {
+			return "", nil, fmt.Errorf("unable to derive mirror address of '%s' without a reference", url)
+		}
+		return mirror, nil, nil
+	}
+
+	registry := ref.Context().RegistryStr()
+	mirrorRef, err := name.ParseReference(strings.Replace(ref.Name(), registry, mirror, 1))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse mirror reference: %w", err)
+	}
+
+	// The image address may be a repository root address, which only
+	// contains the registry host.
+	addr := strings.TrimSuffix(url, "/")
+	if !strings.ContainsRune(addr, '/') {
+		return mirror, mirrorRef, nil
+	}
+	if rest, ok := strings.CutPrefix(url, registry); ok {
+		return mirror + rest, mirrorRef, nil
+	}
+	return mirrorRef.String(), mirrorRef, nil
+}
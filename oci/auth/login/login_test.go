@@ -20,12 +20,20 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	. "github.com/onsi/gomega"
 
 	"github.com/fluxcd/pkg/oci"
@@ -43,10 +51,13 @@ func TestImageRegistryProvider(t *testing.T) {
 		{"ecr", "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1", oci.ProviderAWS},
 		{"ecr-root", "012345678901.dkr.ecr.us-east-1.amazonaws.com", oci.ProviderAWS},
 		{"ecr-root with slash", "012345678901.dkr.ecr.us-east-1.amazonaws.com/", oci.ProviderAWS},
+		{"ecr-public", "public.ecr.aws/foo/bar:v1", oci.ProviderAWS},
+		{"ecr-public-root", "public.ecr.aws", oci.ProviderAWS},
 		{"gcr", "gcr.io/foo/bar:v1", oci.ProviderGCP},
 		{"gcr-root", "gcr.io", oci.ProviderGCP},
 		{"acr", "foo.azurecr.io/bar:v1", oci.ProviderAzure},
 		{"acr-root", "foo.azurecr.io", oci.ProviderAzure},
+		{"acr mixed case", "Foo.AzureCR.io/bar:v1", oci.ProviderAzure},
 		{"docker.io", "foo/bar:v1", oci.ProviderGeneric},
 		{"docker.io-root", "docker.io", oci.ProviderGeneric},
 		{"library", "alpine", oci.ProviderGeneric},
@@ -94,6 +105,26 @@ func TestLogin(t *testing.T) {
 				*image = "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1"
 			},
 		},
+		{
+			name:         "ecr-public",
+			responseBody: `{"authorizationData": {"authorizationToken": "c29tZS1rZXk6c29tZS1zZWNyZXQ="}}`,
+			providerOpts: ProviderOptions{AwsAutoLogin: true},
+			beforeFunc: func(serverURL string, mgr *Manager, image *string) {
+				// Create ECR client and configure the manager.
+				ecrClient := aws.NewClient()
+				cfg := awssdk.NewConfig()
+				cfg.EndpointResolverWithOptions = awssdk.EndpointResolverWithOptionsFunc(
+					func(service, region string, options ...interface{}) (awssdk.Endpoint, error) {
+						return awssdk.Endpoint{URL: serverURL}, nil
+					})
+				cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
+				ecrClient.WithConfig(cfg)
+
+				mgr.WithECRClient(ecrClient)
+
+				*image = "public.ecr.aws/foo/bar:v1"
+			},
+		},
 		{
 			name:         "gcr",
 			responseBody: `{"access_token": "some-token","expires_in": 10, "token_type": "foo"}`,
@@ -161,3 +192,582 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginWithMirrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		image        string
+		nilRef       bool
+		providerOpts ProviderOptions
+		wantHost     string
+		wantErr      []string
+	}{
+		{
+			name:         "primary succeeds",
+			image:        "gcr.io/foo/bar:v1",
+			providerOpts: ProviderOptions{GcpAutoLogin: true, Mirrors: []string{"mirror.gcr.io"}},
+			wantHost:     "gcr.io",
+		},
+		{
+			name:         "primary fails, mirror succeeds",
+			image:        "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1",
+			providerOpts: ProviderOptions{GcpAutoLogin: true, Mirrors: []string{"gcr.io"}},
+			wantHost:     "gcr.io",
+		},
+		{
+			name:  "primary and first mirror fail, second mirror succeeds",
+			image: "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1",
+			providerOpts: ProviderOptions{
+				GcpAutoLogin: true,
+				Mirrors:      []string{"foo.azurecr.io", "gcr.io"},
+			},
+			wantHost: "gcr.io",
+		},
+		{
+			name:  "all fail",
+			image: "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1",
+			providerOpts: ProviderOptions{
+				Mirrors: []string{"gcr.io"},
+			},
+			wantErr: []string{
+				"012345678901.dkr.ecr.us-east-1.amazonaws.com: ECR authentication failed",
+				"gcr.io: GCR authentication failed",
+			},
+		},
+		{
+			name:         "repository root",
+			image:        "012345678901.dkr.ecr.us-east-1.amazonaws.com/",
+			providerOpts: ProviderOptions{GcpAutoLogin: true, Mirrors: []string{"gcr.io"}},
+			wantHost:     "gcr.io",
+		},
+		{
+			name:         "repository root without reference",
+			image:        "012345678901.dkr.ecr.us-east-1.amazonaws.com",
+			nilRef:       true,
+			providerOpts: ProviderOptions{GcpAutoLogin: true, Mirrors: []string{"gcr.io"}},
+			wantHost:     "gcr.io",
+		},
+		{
+			name:         "primary fails without mirrors",
+			image:        "gcr.io/foo/bar:v1",
+			providerOpts: ProviderOptions{},
+			wantErr:      []string{"GCR authentication failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"access_token": "some-token","expires_in": 10, "token_type": "foo"}`))
+			}
+			srv := httptest.NewServer(http.HandlerFunc(handler))
+			t.Cleanup(func() {
+				srv.Close()
+			})
+
+			mgr := NewManager().WithGCRClient(gcp.NewClient().WithTokenURL(srv.URL))
+
+			var ref name.Reference
+			if !tt.nilRef {
+				var err error
+				ref, err = name.ParseReference(strings.TrimSuffix(tt.image, "/"))
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			auth, host, err := mgr.LoginWithMirrors(context.TODO(), tt.image, ref, tt.providerOpts)
+			if len(tt.wantErr) > 0 {
+				g.Expect(err).To(HaveOccurred())
+				for _, e := range tt.wantErr {
+					g.Expect(err.Error()).To(ContainSubstring(e))
+				}
+				g.Expect(err).To(MatchError(oci.ErrUnconfiguredProvider))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(auth).ToNot(BeNil())
+			g.Expect(host).To(Equal(tt.wantHost))
+
+			// Login must not return the credentials of a mirror, as they
+			// would be used for the primary registry.
+			_, err = mgr.Login(context.TODO(), tt.image, ref, tt.providerOpts)
+			g.Expect(err == nil).To(Equal(tt.wantHost == registryAddress(tt.image, ref)))
+
+			// The OIDC login should fall back in the same way.
+			registryURL := "https://" + registryAddress(tt.image, ref)
+			auth, host, err = mgr.OIDCLoginWithMirrors(context.TODO(), registryURL, tt.providerOpts)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(auth).ToNot(BeNil())
+			g.Expect(host).To(Equal(tt.wantHost))
+
+			_, err = mgr.OIDCLogin(context.TODO(), registryURL, tt.providerOpts)
+			g.Expect(err == nil).To(Equal(tt.wantHost == registryAddress(tt.image, ref)))
+		})
+	}
+}
+
+func Test_mirrorReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		nilRef  bool
+		wantURL string
+		wantRef string
+		wantErr bool
+	}{
+		{
+			name:    "image",
+			url:     "gcr.io/foo/bar:v1",
+			wantURL: "mirror.example.com/foo/bar:v1",
+			wantRef: "mirror.example.com/foo/bar:v1",
+		},
+		{
+			name:    "repository root",
+			url:     "gcr.io/",
+			wantURL: "mirror.example.com",
+		},
+		{
+			name:    "image with default registry",
+			url:     "foo/bar:v1",
+			wantURL: "mirror.example.com/foo/bar:v1",
+			wantRef: "mirror.example.com/foo/bar:v1",
+		},
+		{
+			name:    "repository root without reference",
+			url:     "gcr.io",
+			nilRef:  true,
+			wantURL: "mirror.example.com",
+		},
+		{
+			name:    "image without reference",
+			url:     "gcr.io/foo/bar:v1",
+			nilRef:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var ref name.Reference
+			if !tt.nilRef {
+				var err error
+				ref, err = name.ParseReference(strings.TrimSuffix(tt.url, "/"))
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			gotURL, gotRef, err := mirrorReference(tt.url, ref, "mirror.example.com")
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(gotURL).To(Equal(tt.wantURL))
+			if tt.wantRef != "" {
+				g.Expect(gotRef.String()).To(Equal(tt.wantRef))
+			} else if tt.nilRef {
+				g.Expect(gotRef).To(BeNil())
+			}
+		})
+	}
+}
+
+// rewriteTransport sends all requests to the given test server.
+type rewriteTransport struct {
+	serverURL *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.serverURL.Scheme
+	req.URL.Host = t.serverURL.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestLogin_allowAnonymous(t *testing.T) {
+	tests := []struct {
+		name           string
+		public         bool
+		allowAnonymous bool
+		wantAnonymous  bool
+	}{
+		{
+			name:           "public image with anonymous access allowed",
+			public:         true,
+			allowAnonymous: true,
+			wantAnonymous:  true,
+		},
+		{
+			name:           "private image with anonymous access allowed",
+			public:         false,
+			allowAnonymous: true,
+		},
+		{
+			name:           "public image without anonymous access allowed",
+			public:         true,
+			allowAnonymous: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create a registry which rejects anonymous requests for private
+			// images.
+			regHandler := registry.New()
+			regSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.public && r.Header.Get("Authorization") == "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				regHandler.ServeHTTP(w, r)
+			}))
+			t.Cleanup(regSrv.Close)
+			regURL, err := url.Parse(regSrv.URL)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			img, err := random.Image(10, 1)
+			g.Expect(err).ToNot(HaveOccurred())
+			pushRef, err := name.ParseReference(regURL.Host + "/foo:v1")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(remote.Write(pushRef, img,
+				remote.WithAuth(&authn.Basic{Username: "x", Password: "y"}))).To(Succeed())
+
+			// Create a token server which counts the ECR logins.
+			var loginCalls int
+			tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				loginCalls++
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"authorizationData": [{"authorizationToken": "c29tZS1rZXk6c29tZS1zZWNyZXQ="}]}`))
+			}))
+			t.Cleanup(tokenSrv.Close)
+
+			ecrClient := aws.NewClient()
+			cfg := awssdk.NewConfig()
+			cfg.EndpointResolverWithOptions = awssdk.EndpointResolverWithOptionsFunc(
+				func(service, region string, options ...interface{}) (awssdk.Endpoint, error) {
+					return awssdk.Endpoint{URL: tokenSrv.URL}, nil
+				})
+			cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
+			ecrClient.WithConfig(cfg)
+
+			mgr := NewManager().
+				WithECRClient(ecrClient).
+				WithTransport(&rewriteTransport{serverURL: regURL})
+
+			image := "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1"
+			ref, err := name.ParseReference(image)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			auth, err := mgr.Login(context.TODO(), image, ref, ProviderOptions{
+				AwsAutoLogin:   true,
+				AllowAnonymous: tt.allowAnonymous,
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.wantAnonymous {
+				g.Expect(auth).To(Equal(authn.Anonymous))
+				g.Expect(loginCalls).To(Equal(0))
+			} else {
+				g.Expect(auth).ToNot(Equal(authn.Anonymous))
+				g.Expect(loginCalls).To(Equal(1))
+			}
+		})
+	}
+}
+
+func TestLogin_allowAnonymousProxy(t *testing.T) {
+	g := NewWithT(t)
+
+	// Create a registry which also acts as the proxy for the anonymous
+	// access check.
+	var proxiedRequests atomic.Int32
+	regHandler := registry.New()
+	regSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.IsAbs() {
+			proxiedRequests.Add(1)
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(regSrv.Close)
+	regURL, err := url.Parse(regSrv.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	image := "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1"
+	img, err := random.Image(10, 1)
+	g.Expect(err).ToNot(HaveOccurred())
+	pushRef, err := name.ParseReference(regURL.Host + "/foo:v1")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(remote.Write(pushRef, img)).To(Succeed())
+
+	ref, err := name.ParseReference(image, name.Insecure)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	auth, err := NewManager().Login(context.TODO(), image, ref, ProviderOptions{
+		AwsAutoLogin:   true,
+		AllowAnonymous: true,
+		ProxyURL:       regURL,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(auth).To(Equal(authn.Anonymous))
+	g.Expect(proxiedRequests.Load()).To(BeNumerically(">", 0))
+}
+
+func TestLogin_proxy(t *testing.T) {
+	tests := []struct {
+		name         string
+		image        string
+		responseBody string
+		providerOpts ProviderOptions
+		wantProxied  []string
+	}{
+		{
+			name:         "ecr",
+			image:        "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1",
+			responseBody: `{"authorizationData": [{"authorizationToken": "c29tZS1rZXk6c29tZS1zZWNyZXQ="}]}`,
+			providerOpts: ProviderOptions{AwsAutoLogin: true},
+			wantProxied:  []string{"ecr.example.com"},
+		},
+		{
+			// The metadata server is always reached without the proxy.
+			name:         "gcr",
+			image:        "gcr.io/foo/bar:v1",
+			responseBody: `{"access_token": "some-token","expires_in": 10, "token_type": "foo"}`,
+			providerOpts: ProviderOptions{GcpAutoLogin: true},
+		},
+		{
+			name:         "acr",
+			image:        "foo.azurecr.io/bar:v1",
+			responseBody: `{"refresh_token": "bbbbb"}`,
+			providerOpts: ProviderOptions{AzureAutoLogin: true},
+			wantProxied:  []string{"foo.azurecr.io"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create a proxy which responds to all the requests itself.
+			var (
+				mu           sync.Mutex
+				proxiedHosts []string
+			)
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				proxiedHosts = append(proxiedHosts, r.URL.Host)
+				mu.Unlock()
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.responseBody))
+			}))
+			t.Cleanup(proxy.Close)
+			proxyURL, err := url.Parse(proxy.URL)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// Create a metadata server which must be reached directly.
+			var metadataRequests atomic.Int32
+			metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				metadataRequests.Add(1)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.responseBody))
+			}))
+			t.Cleanup(metadata.Close)
+
+			ecrClient := aws.NewClient()
+			cfg := awssdk.NewConfig()
+			cfg.EndpointResolverWithOptions = awssdk.EndpointResolverWithOptionsFunc(
+				func(service, region string, options ...interface{}) (awssdk.Endpoint, error) {
+					return awssdk.Endpoint{URL: "http://ecr.example.com"}, nil
+				})
+			cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
+			cfg.RetryMaxAttempts = 1
+			ecrClient.WithConfig(cfg)
+
+			mgr := NewManager().
+				WithECRClient(ecrClient).
+				WithGCRClient(gcp.NewClient().WithTokenURL(metadata.URL)).
+				WithACRClient(azure.NewClient().WithTokenCredential(&azure.FakeTokenCredential{Token: "foo"}).WithScheme("http"))
+
+			ref, err := name.ParseReference(tt.image)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			opts := tt.providerOpts
+			opts.ProxyURL = proxyURL
+			auth, err := mgr.Login(context.TODO(), tt.image, ref, opts)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(auth).ToNot(BeNil())
+			mu.Lock()
+			g.Expect(proxiedHosts).To(ConsistOf(tt.wantProxied))
+			mu.Unlock()
+
+			// A later login without a proxy must not use the proxy of the
+			// previous login. The token endpoints of ECR and ACR can not be
+			// reached without the proxy, hence only the GCR login succeeds.
+			_, err = mgr.Login(context.TODO(), tt.image, ref, tt.providerOpts)
+			g.Expect(err == nil).To(Equal(len(tt.wantProxied) == 0))
+			mu.Lock()
+			g.Expect(proxiedHosts).To(ConsistOf(tt.wantProxied))
+			mu.Unlock()
+			if len(tt.wantProxied) == 0 {
+				g.Expect(metadataRequests.Load()).To(Equal(int32(2)))
+			}
+		})
+	}
+}
+
+func TestLogin_proxyConcurrent(t *testing.T) {
+	g := NewWithT(t)
+
+	newServer := func(requests *atomic.Int32) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"authorizationData": [{"authorizationToken": "c29tZS1rZXk6c29tZS1zZWNyZXQ="}]}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	var directRequests, proxiedRequests atomic.Int32
+	direct := newServer(&directRequests)
+	proxy := newServer(&proxiedRequests)
+	proxyURL, err := url.Parse(proxy.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ecrClient := aws.NewClient()
+	cfg := awssdk.NewConfig()
+	cfg.EndpointResolverWithOptions = awssdk.EndpointResolverWithOptionsFunc(
+		func(service, region string, options ...interface{}) (awssdk.Endpoint, error) {
+			return awssdk.Endpoint{URL: direct.URL}, nil
+		})
+	cfg.Credentials = credentials.NewStaticCredentialsProvider("x", "y", "z")
+	ecrClient.WithConfig(cfg)
+	mgr := NewManager().WithECRClient(ecrClient)
+
+	image := "012345678901.dkr.ecr.us-east-1.amazonaws.com/foo:v1"
+	ref, err := name.ParseReference(image)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Logins with and without a proxy share the Manager, and must only
+	// use the proxy they are given.
+	const logins = 10
+	errs := make(chan error, logins)
+	var wg sync.WaitGroup
+	for i := 0; i < logins; i++ {
+		opts := ProviderOptions{AwsAutoLogin: true}
+		if i%2 == 0 {
+			opts.ProxyURL = proxyURL
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mgr.Login(context.TODO(), image, ref, opts)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(proxiedRequests.Load()).To(Equal(int32(logins / 2)))
+	g.Expect(directRequests.Load()).To(Equal(int32(logins / 2)))
+}
+
+func TestLogin_retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCodes  []int
+		retry        RetryOptions
+		timeout      time.Duration
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "retries until success",
+			statusCodes:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			retry:        RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond},
+			wantRequests: 3,
+		},
+		{
+			name:         "retries throttled requests",
+			statusCodes:  []int{http.StatusTooManyRequests, http.StatusOK},
+			retry:        RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond},
+			wantRequests: 2,
+		},
+		{
+			name:         "no retries by default",
+			statusCodes:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "retries exhausted",
+			statusCodes:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			retry:        RetryOptions{MaxRetries: 1, InitialBackoff: time.Millisecond},
+			wantRequests: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "authentication failures are not retried",
+			statusCodes:  []int{http.StatusForbidden, http.StatusOK},
+			retry:        RetryOptions{MaxRetries: 3, InitialBackoff: time.Millisecond},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "backoff past the context deadline",
+			statusCodes:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			retry:        RetryOptions{MaxRetries: 3, InitialBackoff: time.Minute},
+			timeout:      10 * time.Second,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				code := tt.statusCodes[min(requests, len(tt.statusCodes)-1)]
+				requests++
+				w.WriteHeader(code)
+				if code == http.StatusOK {
+					w.Write([]byte(`{"access_token": "some-token","expires_in": 10, "token_type": "foo"}`))
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			mgr := NewManager().WithGCRClient(gcp.NewClient().WithTokenURL(srv.URL))
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				t.Cleanup(cancel)
+			}
+
+			image := "gcr.io/foo/bar:v1"
+			ref, err := name.ParseReference(image)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			auth, err := mgr.Login(ctx, image, ref, ProviderOptions{
+				GcpAutoLogin: true,
+				Retry:        tt.retry,
+			})
+			g.Expect(requests).To(Equal(tt.wantRequests))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(auth).ToNot(BeNil())
+		})
+	}
+}
@@ -26,8 +26,10 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
 
+	"github.com/fluxcd/pkg/oci/auth/login"
 	"github.com/fluxcd/pkg/version"
 )
 
@@ -121,6 +123,32 @@ func (c *Client) List(ctx context.Context, url string, opts ListOptions) ([]Meta
 	return metas, nil
 }
 
+// ListTags logs in to the registry of the given OCI repository using the
+// login Manager and provider options, and returns the tags of the
+// repository. For a generic registry provider, the tags are listed using
+// the options of the client only.
+func (c *Client) ListTags(ctx context.Context, url string, manager *login.Manager, opts login.ProviderOptions) ([]string, error) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not create reference from url '%s': %w", url, err)
+	}
+
+	auth, err := manager.Login(ctx, url, ref, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not login to registry of '%s': %w", url, err)
+	}
+
+	options := c.optionsWithContext(ctx)
+	if auth != nil {
+		options = append(options, crane.WithAuth(auth))
+	}
+	tags, err := crane.ListTags(url, options...)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags failed: %w", err)
+	}
+	return tags, nil
+}
+
 // IsCosignArtifact will return true if the tag has one of the following suffices:
 // ".att", ".sbom", or ".sig". These are the suffices used by cosign to store the
 // attestations, SBOMs, and signatures respectively.
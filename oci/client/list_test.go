@@ -19,6 +19,8 @@ package client
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -28,6 +30,9 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	. "github.com/onsi/gomega"
+
+	"github.com/fluxcd/pkg/oci/auth/gcp"
+	"github.com/fluxcd/pkg/oci/auth/login"
 )
 
 func Test_List(t *testing.T) {
@@ -142,3 +147,82 @@ func Test_List(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ListTags(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	repo := "test-list-tags" + randStringRunes(5)
+	tags := []string{"v0.0.1", "v0.0.2", "latest"}
+	for _, tag := range tags {
+		img, err := random.Image(1024, 1)
+		g.Expect(err).ToNot(HaveOccurred())
+		err = crane.Push(img, fmt.Sprintf("%s/%s:%s", dockerReg, repo, tag))
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	var tokenRequests int
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"access_token": "some-token","expires_in": 10, "token_type": "foo"}`))
+	}))
+	t.Cleanup(tokenSrv.Close)
+
+	tests := []struct {
+		name              string
+		url               string
+		providerOpts      login.ProviderOptions
+		wantTokenRequests int
+		wantErr           string
+	}{
+		{
+			name:              "provider registry",
+			url:               "gcr.io/" + repo,
+			providerOpts:      login.ProviderOptions{GcpAutoLogin: true},
+			wantTokenRequests: 1,
+		},
+		{
+			name: "generic registry",
+			url:  dockerReg + "/" + repo,
+		},
+		{
+			name:    "provider login failure",
+			url:     "gcr.io/" + repo,
+			wantErr: "could not login to registry of 'gcr.io/" + repo + "'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tokenRequests = 0
+
+			c := NewClient([]crane.Option{
+				crane.WithTransport(&rewriteTransport{host: dockerReg}),
+			})
+			manager := login.NewManager().WithGCRClient(gcp.NewClient().WithTokenURL(tokenSrv.URL))
+
+			got, err := c.ListTags(ctx, tt.url, manager, tt.providerOpts)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(ConsistOf(tags))
+			g.Expect(tokenRequests).To(Equal(tt.wantTokenRequests))
+		})
+	}
+}
+
+// rewriteTransport sends all requests to the given registry host over HTTP.
+type rewriteTransport struct {
+	host string
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = t.host
+	return http.DefaultTransport.RoundTrip(req)
+}
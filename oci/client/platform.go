@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ResolvePlatform returns the digest of the manifest matching the given
+// platform for the artifact at the given URL. If the artifact is a manifest
+// list, the digest of the first manifest whose platform satisfies the given
+// platform is returned. Else, the digest of the artifact itself is returned.
+func (c *Client) ResolvePlatform(ctx context.Context, url string, platform gcrv1.Platform) (string, error) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	options := crane.GetOptions(c.optionsWithContext(ctx)...).Remote
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return "", fmt.Errorf("fetching manifest failed: %w", err)
+	}
+	if !desc.MediaType.IsIndex() {
+		return desc.Digest.String(), nil
+	}
+
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return "", fmt.Errorf("parsing manifest list failed: %w", err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return "", fmt.Errorf("parsing manifest list failed: %w", err)
+	}
+	for _, m := range manifest.Manifests {
+		if m.Platform != nil && m.Platform.Satisfies(platform) {
+			return m.Digest.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no manifest found for platform '%s' in '%s'", platform.String(), url)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/gomega"
+)
+
+func TestClient_ResolvePlatform(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	srv := httptest.NewServer(registry.New())
+	t.Cleanup(srv.Close)
+	repo := strings.TrimPrefix(srv.URL, "http://") + "/test-platform"
+
+	platforms := []gcrv1.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	}
+	digests := map[string]string{}
+	var adds []mutate.IndexAddendum
+	for _, p := range platforms {
+		img, err := random.Image(64, 1)
+		g.Expect(err).ToNot(HaveOccurred())
+		digest, err := img.Digest()
+		g.Expect(err).ToNot(HaveOccurred())
+		digests[p.String()] = digest.String()
+		adds = append(adds, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: gcrv1.Descriptor{Platform: &p},
+		})
+	}
+	indexRef, err := name.ParseReference(repo + ":multi-arch")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(remote.WriteIndex(indexRef, mutate.AppendManifests(empty.Index, adds...))).To(Succeed())
+
+	img, err := random.Image(64, 1)
+	g.Expect(err).ToNot(HaveOccurred())
+	imgDigest, err := img.Digest()
+	g.Expect(err).ToNot(HaveOccurred())
+	imgRef, err := name.ParseReference(repo + ":single")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(remote.Write(imgRef, img)).To(Succeed())
+
+	tests := []struct {
+		name       string
+		url        string
+		platform   gcrv1.Platform
+		wantDigest string
+		wantErr    string
+	}{
+		{
+			name:       "amd64",
+			url:        indexRef.String(),
+			platform:   gcrv1.Platform{OS: "linux", Architecture: "amd64"},
+			wantDigest: digests["linux/amd64"],
+		},
+		{
+			name:       "arm64 with variant",
+			url:        indexRef.String(),
+			platform:   gcrv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			wantDigest: digests["linux/arm64/v8"],
+		},
+		{
+			name:       "arm64 without variant",
+			url:        indexRef.String(),
+			platform:   gcrv1.Platform{OS: "linux", Architecture: "arm64"},
+			wantDigest: digests["linux/arm64/v8"],
+		},
+		{
+			name:     "missing platform",
+			url:      indexRef.String(),
+			platform: gcrv1.Platform{OS: "windows", Architecture: "amd64"},
+			wantErr:  "no manifest found for platform 'windows/amd64'",
+		},
+		{
+			name:       "single manifest",
+			url:        imgRef.String(),
+			platform:   gcrv1.Platform{OS: "linux", Architecture: "amd64"},
+			wantDigest: imgDigest.String(),
+		},
+		{
+			name:     "invalid URL",
+			url:      "example.com/foo:bar:v1",
+			platform: gcrv1.Platform{OS: "linux", Architecture: "amd64"},
+			wantErr:  "invalid URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := NewClient(DefaultOptions())
+			got, err := c.ResolvePlatform(ctx, tt.url, tt.platform)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.wantDigest))
+		})
+	}
+}
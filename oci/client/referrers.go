@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Referrers returns the descriptors of the artifacts referring to the given
+// digest reference, such as signatures and SBOMs. If artifactType is not
+// empty, only the referrers of that artifact type are returned.
+// For registries which do not support the OCI referrers API, the referrers
+// are looked up using the referrers tag schema.
+func (c *Client) Referrers(ctx context.Context, url, artifactType string) ([]gcrv1.Descriptor, error) {
+	digest, err := name.NewDigest(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest URL: %w", err)
+	}
+
+	options := crane.GetOptions(c.optionsWithContext(ctx)...).Remote
+	if artifactType != "" {
+		options = append(options, remote.WithFilter("artifactType", artifactType))
+	}
+
+	index, err := remote.Referrers(digest, options...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching referrers failed: %w", err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("parsing referrers failed: %w", err)
+	}
+	return manifest.Manifests, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/gomega"
+)
+
+func TestClient_Referrers(t *testing.T) {
+	const (
+		sigType  = types.MediaType("application/vnd.dev.cosign.artifact.sig.v1+json")
+		sbomType = types.MediaType("application/spdx+json")
+	)
+
+	tests := []struct {
+		name              string
+		referrersSupport  bool
+		artifactType      string
+		wantArtifactTypes []string
+	}{
+		{
+			name:              "referrers API",
+			referrersSupport:  true,
+			wantArtifactTypes: []string{string(sigType), string(sbomType)},
+		},
+		{
+			name:              "referrers API with artifact type",
+			referrersSupport:  true,
+			artifactType:      string(sbomType),
+			wantArtifactTypes: []string{string(sbomType)},
+		},
+		{
+			name:              "referrers tag schema fallback",
+			wantArtifactTypes: []string{string(sigType), string(sbomType)},
+		},
+		{
+			name:              "referrers tag schema fallback with artifact type",
+			artifactType:      string(sigType),
+			wantArtifactTypes: []string{string(sigType)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			srv := httptest.NewServer(registry.New(registry.WithReferrersSupport(tt.referrersSupport)))
+			t.Cleanup(srv.Close)
+			repo := strings.TrimPrefix(srv.URL, "http://") + "/test-referrers"
+
+			img, err := random.Image(1024, 1)
+			g.Expect(err).ToNot(HaveOccurred())
+			ref, err := name.ParseReference(repo + ":v1")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(remote.Write(ref, img)).To(Succeed())
+			subject, err := remote.Head(ref)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			for i, mt := range []types.MediaType{sigType, sbomType} {
+				artifact, err := random.Image(64, 1)
+				g.Expect(err).ToNot(HaveOccurred())
+				artifact = mutate.MediaType(artifact, types.OCIManifestSchema1)
+				artifact = mutate.ConfigMediaType(artifact, mt)
+				artifact = mutate.Subject(artifact, *subject).(gcrv1.Image)
+				artifactRef, err := name.ParseReference(fmt.Sprintf("%s:artifact-%d", repo, i))
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(remote.Write(artifactRef, artifact)).To(Succeed())
+			}
+
+			c := NewClient(DefaultOptions())
+			got, err := c.Referrers(ctx, fmt.Sprintf("%s@%s", repo, subject.Digest), tt.artifactType)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var artifactTypes []string
+			for _, desc := range got {
+				artifactTypes = append(artifactTypes, desc.ArtifactType)
+			}
+			g.Expect(artifactTypes).To(ConsistOf(tt.wantArtifactTypes))
+		})
+	}
+}
+
+func TestClient_Referrers_invalidURL(t *testing.T) {
+	g := NewWithT(t)
+
+	c := NewClient(DefaultOptions())
+	_, err := c.Referrers(context.Background(), "example.com/foo:v1", "")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid digest URL")))
+}
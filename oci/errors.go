@@ -23,3 +23,26 @@ var (
 	// not configured.
 	ErrUnconfiguredProvider = errors.New("registry provider not configured")
 )
+
+// StatusError is returned by the registry providers when a token exchange
+// request fails with an unexpected HTTP status code.
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Err describes the failure.
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusCode returns the HTTP status code of the response. It matches
+// the method of the response errors of the AWS SDK.
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
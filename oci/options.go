@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import "net/url"
+
+// LoginOptions contains options for the login of a registry provider
+// client.
+type LoginOptions struct {
+	// ProxyURL is the URL of the proxy to use for the requests made to
+	// obtain and exchange tokens. If nil, the proxy is determined by the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, as
+	// with the default HTTP transport.
+	ProxyURL *url.URL
+}
+
+// LoginOption configures the login of a registry provider client.
+type LoginOption func(*LoginOptions)
+
+// WithProxyURL sets the URL of the proxy to use for the requests of the
+// login.
+func WithProxyURL(proxyURL *url.URL) LoginOption {
+	return func(o *LoginOptions) {
+		o.ProxyURL = proxyURL
+	}
+}
+
+// NewLoginOptions returns the LoginOptions configured by the given options.
+func NewLoginOptions(opts ...LoginOption) LoginOptions {
+	var o LoginOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
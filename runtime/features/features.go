@@ -18,6 +18,8 @@ package features
 
 import (
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/pflag"
@@ -26,10 +28,20 @@ import (
 
 const (
 	flagFeatureGates = "feature-gates"
+
+	// EnvFeatureGates is the environment variable which can be used to set
+	// feature gates, in the same comma separated key=value format as the
+	// --feature-gates flag. Feature gates set with the flag take precedence.
+	EnvFeatureGates = "FLUX_FEATURE_GATES"
 )
 
-var featureGates map[string]bool
-var loaded bool
+var (
+	// mu guards featureGates and loaded, which may be read by Enabled
+	// concurrently with SupportedFeatures being called.
+	mu           sync.RWMutex
+	featureGates map[string]bool
+	loaded       bool
+)
 
 // FeatureGates is a helper to manage feature switches.
 //
@@ -50,25 +62,29 @@ func (o *FeatureGates) WithLogger(l logr.Logger) *FeatureGates {
 }
 
 // SupportedFeatures sets the supported features and their default values.
+// The default values are overridden by the feature gates set with the
+// EnvFeatureGates environment variable (see LoadFeatureGatesFromEnv), and
+// then by those set with the --feature-gates flag.
 func (o *FeatureGates) SupportedFeatures(features map[string]bool) error {
+	envFeatures, err := featureGatesFromEnv()
+	if err == nil {
+		err = override(o.log, features, envFeatures)
+	}
+	if err == nil {
+		err = override(o.log, features, o.cliFeatures)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
 	loaded = true
 	featureGates = features
-
-	for k, v := range o.cliFeatures {
-		if _, ok := featureGates[k]; ok {
-			featureGates[k] = v
-		} else {
-			return fmt.Errorf("feature-gate '%s' not supported", k)
-		}
-		if o.log != nil {
-			o.log.Info("loading feature gate", k, v)
-		}
-	}
-	return nil
+	return err
 }
 
 // Enabled verifies whether the feature is enabled or not.
 func Enabled(feature string) (bool, error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	if !loaded {
 		return false, fmt.Errorf("supported features not set")
 	}
@@ -78,6 +94,49 @@ func Enabled(feature string) (bool, error) {
 	return false, fmt.Errorf("feature-gate '%s' not supported", feature)
 }
 
+// LoadFeatureGatesFromEnv overrides the values in features with the
+// feature gates set with the EnvFeatureGates environment variable,
+// returning an error if the variable is malformed or sets a feature gate
+// that is not in features. It can be used by callers that do not bind
+// the --feature-gates flag; SupportedFeatures calls it before applying
+// the flag, so the precedence is flag > environment > defaults.
+func LoadFeatureGatesFromEnv(features map[string]bool) error {
+	envFeatures, err := featureGatesFromEnv()
+	if err != nil {
+		return err
+	}
+	return override(nil, features, envFeatures)
+}
+
+// override sets the values of the given overrides in gates, returning an
+// error if a feature gate is not supported.
+func override(log *logr.Logger, gates, overrides map[string]bool) error {
+	for k, v := range overrides {
+		if _, ok := gates[k]; !ok {
+			return fmt.Errorf("feature-gate '%s' not supported", k)
+		}
+		gates[k] = v
+		if log != nil {
+			log.Info("loading feature gate", k, v)
+		}
+	}
+	return nil
+}
+
+// featureGatesFromEnv parses the feature gates set with the
+// EnvFeatureGates environment variable.
+func featureGatesFromEnv() (map[string]bool, error) {
+	var features map[string]bool
+	v, ok := os.LookupEnv(EnvFeatureGates)
+	if !ok {
+		return nil, nil
+	}
+	if err := cliflag.NewMapStringBool(&features).Set(v); err != nil {
+		return nil, fmt.Errorf("invalid %s value: %w", EnvFeatureGates, err)
+	}
+	return features, nil
+}
+
 // BindFlags will parse the given pflag.FlagSet and load feature gates accordingly.
 func (o *FeatureGates) BindFlags(fs *pflag.FlagSet) {
 	fs.Var(cliflag.NewMapStringBool(&o.cliFeatures), flagFeatureGates,
@@ -17,6 +17,7 @@ limitations under the License.
 package features
 
 import (
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -151,3 +152,155 @@ func TestEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestEnabled_concurrent(t *testing.T) {
+	g := NewWithT(t)
+
+	features := FeatureGates{}
+	g.Expect(features.SupportedFeatures(map[string]bool{"time-travel": false})).To(Succeed())
+
+	// Failures are collected and asserted on the test goroutine.
+	const n = 10
+	errs := make(chan error, 2*n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(enabled bool) {
+			defer wg.Done()
+			features := FeatureGates{cliFeatures: map[string]bool{"time-travel": enabled}}
+			errs <- features.SupportedFeatures(map[string]bool{"time-travel": false})
+		}(i%2 == 0)
+		go func() {
+			defer wg.Done()
+			_, err := Enabled("time-travel")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+}
+
+func TestSupportedFeatures_env(t *testing.T) {
+	tests := []struct {
+		name              string
+		env               string
+		commandLine       []string
+		supportedFeatures map[string]bool
+		want              map[string]bool
+		wantErr           string
+	}{
+		{
+			name:              "env overrides default values",
+			env:               "invisible-messages=false,time-travel=true",
+			supportedFeatures: map[string]bool{"invisible-messages": true, "time-travel": false},
+			want:              map[string]bool{"invisible-messages": false, "time-travel": true},
+		},
+		{
+			name:              "flags override env",
+			env:               "invisible-messages=false,time-travel=true",
+			commandLine:       []string{"--feature-gates=time-travel=false"},
+			supportedFeatures: map[string]bool{"invisible-messages": true, "time-travel": false},
+			want:              map[string]bool{"invisible-messages": false, "time-travel": false},
+		},
+		{
+			name:              "empty env",
+			env:               "",
+			supportedFeatures: map[string]bool{"time-travel": true},
+			want:              map[string]bool{"time-travel": true},
+		},
+		{
+			name:              "env sets feature gate that is not supported",
+			env:               "time-travel=true",
+			supportedFeatures: map[string]bool{},
+			wantErr:           "feature-gate 'time-travel' not supported",
+		},
+		{
+			name:              "malformed env",
+			env:               "time-travel",
+			supportedFeatures: map[string]bool{"time-travel": false},
+			wantErr:           "invalid FLUX_FEATURE_GATES value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(EnvFeatureGates, tt.env)
+			fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+
+			features := FeatureGates{}
+			features.BindFlags(fs)
+			fs.Parse(tt.commandLine)
+
+			err := features.SupportedFeatures(tt.supportedFeatures)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).Should(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			for k, v := range tt.want {
+				enabled, err := Enabled(k)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(enabled).To(Equal(v), k)
+			}
+		})
+	}
+}
+
+func TestLoadFeatureGatesFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		features map[string]bool
+		want     map[string]bool
+		wantErr  string
+	}{
+		{
+			name:     "empty env",
+			features: map[string]bool{"time-travel": false},
+			want:     map[string]bool{"time-travel": false},
+		},
+		{
+			name:     "env overrides default values",
+			env:      "invisible-messages=false,time-travel=true",
+			features: map[string]bool{"invisible-messages": true, "time-travel": false},
+			want:     map[string]bool{"invisible-messages": false, "time-travel": true},
+		},
+		{
+			name:     "env sets feature gate that is not supported",
+			env:      "time-travel=true",
+			features: map[string]bool{},
+			wantErr:  "feature-gate 'time-travel' not supported",
+		},
+		{
+			name:     "malformed env",
+			env:      "time-travel",
+			features: map[string]bool{"time-travel": false},
+			wantErr:  "invalid FLUX_FEATURE_GATES value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			t.Setenv(EnvFeatureGates, tt.env)
+
+			err := LoadFeatureGatesFromEnv(tt.features)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).Should(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(tt.features).To(Equal(tt.want))
+		})
+	}
+}
@@ -33,6 +33,9 @@ import (
 // clientHostKeyAlgos defines what HostKey algorithms to be
 // used by the ssh client when using `ssh.Dial`. The default is
 // empty, which defaults to Golang's preferred HostKey algorithms.
+// When set, only the given algorithms are negotiated, in order of
+// preference, and an error is returned if the host offers none of
+// them. This allows e.g. excluding `ssh-rsa` in favor of `ssh-ed25519`.
 func ScanHostKey(host string, timeout time.Duration, clientHostKeyAlgos []string, hashKeys bool) ([]byte, error) {
 	col := &HostKeyCollector{hashKeys: hashKeys}
 	config := &ssh.ClientConfig{
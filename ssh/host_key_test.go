@@ -18,6 +18,7 @@ package ssh
 
 import (
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -100,3 +101,89 @@ func TestScanHost(t *testing.T) {
 		})
 	}
 }
+
+func TestScanHostKey_HostKeyAlgos(t *testing.T) {
+	sshConfig := &ssh.ServerConfig{
+		NoClientAuth: true,
+	}
+	// Advertise multiple host key types from the same server.
+	for _, kt := range []KeyPairType{RSA_4096, ECDSA_P256, ED25519} {
+		kp, err := GenerateKeyPair(kt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := ssh.ParsePrivateKey(kp.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sshConfig.AddHostKey(signer)
+	}
+
+	tests := []struct {
+		name      string
+		algos     []string
+		wantTypes []string
+		wantErr   string
+	}{
+		{
+			name:      "single algorithm",
+			algos:     []string{ssh.KeyAlgoED25519},
+			wantTypes: []string{ssh.KeyAlgoED25519},
+		},
+		{
+			name:      "first supported algorithm is preferred",
+			algos:     []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoED25519},
+			wantTypes: []string{ssh.KeyAlgoECDSA256},
+		},
+		{
+			name:      "unknown algorithms are ignored",
+			algos:     []string{"unknown-algo", ssh.KeyAlgoED25519},
+			wantTypes: []string{ssh.KeyAlgoED25519},
+		},
+		{
+			name:    "no requested algorithm offered",
+			algos:   []string{ssh.KeyAlgoECDSA521},
+			wantErr: "no common algorithm for host key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			g.Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				sConn, _, _, err := ssh.NewServerConn(conn, sshConfig)
+				if err != nil {
+					return
+				}
+				sConn.Close()
+			}()
+
+			kh, err := ScanHostKey(listener.Addr().String(), 5*time.Second, tt.algos, false)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(kh).To(BeEmpty())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var gotTypes []string
+			for _, line := range strings.Split(strings.TrimSpace(string(kh)), "\n") {
+				_, _, pub, _, _, err := ssh.ParseKnownHosts([]byte(line))
+				g.Expect(err).ToNot(HaveOccurred())
+				gotTypes = append(gotTypes, pub.Type())
+			}
+			g.Expect(gotTypes).To(Equal(tt.wantTypes))
+			g.Expect(string(kh)).ToNot(ContainSubstring(ssh.KeyAlgoRSA))
+		})
+	}
+}
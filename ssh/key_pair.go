@@ -103,10 +103,15 @@ func (g *RSAGenerator) Generate() (*KeyPair, error) {
 	}, nil
 }
 
+// ECDSAGenerator generates ecdsa keypairs on a given elliptic curve. The
+// public key is encoded in the OpenSSH authorized_keys format, the private
+// key as a PKCS#8 PEM block.
 type ECDSAGenerator struct {
 	c elliptic.Curve
 }
 
+// NewECDSAGenerator returns a KeyPairGenerator for ecdsa keypairs using the
+// given curve, which must be one of P-256, P-384 or P-521.
 func NewECDSAGenerator(c elliptic.Curve) KeyPairGenerator {
 	return &ECDSAGenerator{c}
 }
@@ -130,8 +135,12 @@ func (g *ECDSAGenerator) Generate() (*KeyPair, error) {
 	}, nil
 }
 
+// Ed25519Generator generates ed25519 keypairs. The public key is encoded
+// in the OpenSSH authorized_keys format, the private key as a PKCS#8
+// PEM block.
 type Ed25519Generator struct{}
 
+// NewEd25519Generator returns a KeyPairGenerator for ed25519 keypairs.
 func NewEd25519Generator() KeyPairGenerator {
 	return &Ed25519Generator{}
 }
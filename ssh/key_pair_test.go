@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssh
+
+import (
+	"crypto/elliptic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestEd25519Generator_Generate(t *testing.T) {
+	g := NewWithT(t)
+
+	pair, err := NewEd25519Generator().Generate()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pair).ToNot(BeNil())
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(pair.PublicKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pub.Type()).To(Equal(ssh.KeyAlgoED25519))
+
+	signer, err := ssh.ParsePrivateKey(pair.PrivateKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(signer.PublicKey().Marshal()).To(Equal(pub.Marshal()))
+}
+
+func TestECDSAGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve elliptic.Curve
+		algo  string
+	}{
+		{name: "P-256", curve: elliptic.P256(), algo: ssh.KeyAlgoECDSA256},
+		{name: "P-384", curve: elliptic.P384(), algo: ssh.KeyAlgoECDSA384},
+		{name: "P-521", curve: elliptic.P521(), algo: ssh.KeyAlgoECDSA521},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			pair, err := NewECDSAGenerator(tt.curve).Generate()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(pair).ToNot(BeNil())
+			g.Expect(string(pair.PublicKey)).To(HavePrefix(tt.algo + " "))
+
+			pub, _, _, _, err := ssh.ParseAuthorizedKey(pair.PublicKey)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(pub.Type()).To(Equal(tt.algo))
+
+			signer, err := ssh.ParsePrivateKey(pair.PrivateKey)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(signer.PublicKey().Marshal()).To(Equal(pub.Marshal()))
+		})
+	}
+}
+
+func TestGenerateKeyPair(t *testing.T) {
+	tests := []struct {
+		keyType KeyPairType
+		algo    string
+		wantErr string
+	}{
+		{keyType: ECDSA_P256, algo: ssh.KeyAlgoECDSA256},
+		{keyType: ECDSA_P384, algo: ssh.KeyAlgoECDSA384},
+		{keyType: ECDSA_P521, algo: ssh.KeyAlgoECDSA521},
+		{keyType: ED25519, algo: ssh.KeyAlgoED25519},
+		{keyType: "invalid", wantErr: "unsupported key type: invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.keyType), func(t *testing.T) {
+			g := NewWithT(t)
+
+			pair, err := GenerateKeyPair(tt.keyType)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			pub, _, _, _, err := ssh.ParseAuthorizedKey(pair.PublicKey)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(pub.Type()).To(Equal(tt.algo))
+
+			signer, err := ssh.ParsePrivateKey(pair.PrivateKey)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(signer.PublicKey().Type()).To(Equal(tt.algo))
+		})
+	}
+}
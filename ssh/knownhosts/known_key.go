@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 type KnownKey struct {
@@ -76,6 +77,25 @@ func (k KnownKey) Matches(host string, fingerprint []byte) bool {
 	return bytes.Equal(hasher.Sum(nil), fingerprint)
 }
 
+// HostMatches parses the given known_hosts content and reports whether any
+// of its entries matches the host, regardless of the entry's hostname being
+// in plaintext or hashed (HMAC-SHA1) form. The host is normalized the same
+// way as in a known_hosts file, e.g. "example.com:2222" is matched as
+// "[example.com]:2222" and "example.com:22" as "example.com".
+func HostMatches(knownHosts []byte, host string) (bool, error) {
+	knownKeys, err := ParseKnownHosts(string(knownHosts))
+	if err != nil {
+		return false, err
+	}
+	h := knownhosts.Normalize(host)
+	for _, k := range knownKeys {
+		if containsHost(k.hosts, h) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func containsHost(hosts []string, host string) bool {
 	for _, kh := range hosts {
 		// hashed host must start with a pipe
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 // knownHostsFixture is known_hosts fixture in the expected
@@ -187,6 +188,82 @@ gitlab.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAfuCHKVTjquxvt6CM6tdG4SLp1Btn/nO
 	}
 }
 
+func TestHostMatches(t *testing.T) {
+	const hostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAfuCHKVTjquxvt6CM6tdG4SLp1Btn/nOeHHE5UOzRdf"
+
+	tests := []struct {
+		name       string
+		knownHosts string
+		host       string
+		wantMatch  bool
+		wantErr    bool
+	}{
+		{
+			name:       "plaintext entry",
+			knownHosts: "gitlab.com " + hostKey,
+			host:       "gitlab.com",
+			wantMatch:  true,
+		},
+		{
+			name:       "hashed entry",
+			knownHosts: knownhosts.HashHostname("gitlab.com") + " " + hostKey,
+			host:       "gitlab.com",
+			wantMatch:  true,
+		},
+		{
+			name:       "hashed entry with default port",
+			knownHosts: knownhosts.HashHostname("gitlab.com") + " " + hostKey,
+			host:       "gitlab.com:22",
+			wantMatch:  true,
+		},
+		{
+			name:       "hashed entry with custom port",
+			knownHosts: knownhosts.HashHostname("[gitlab.com]:2222") + " " + hostKey,
+			host:       "gitlab.com:2222",
+			wantMatch:  true,
+		},
+		{
+			name: "mixed plaintext and hashed entries",
+			knownHosts: "# comment\n" +
+				"github.com " + hostKey + "\n" +
+				knownhosts.HashHostname("gitlab.com") + " " + hostKey,
+			host:      "gitlab.com",
+			wantMatch: true,
+		},
+		{
+			name:       "hashed entry for other host",
+			knownHosts: knownhosts.HashHostname("github.com") + " " + hostKey,
+			host:       "gitlab.com",
+			wantMatch:  false,
+		},
+		{
+			name:       "hashed entry for other port",
+			knownHosts: knownhosts.HashHostname("gitlab.com") + " " + hostKey,
+			host:       "gitlab.com:2222",
+			wantMatch:  false,
+		},
+		{
+			name:       "invalid content",
+			knownHosts: "some random text",
+			host:       "gitlab.com",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			matches, err := HostMatches([]byte(tt.knownHosts), tt.host)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(matches).To(Equal(tt.wantMatch))
+		})
+	}
+}
+
 func sha256Fingerprint(in string) []byte {
 	d, err := base64.RawStdEncoding.DecodeString(in)
 	if err != nil {